@@ -42,6 +42,32 @@ type BlobReader interface {
 	Read(context.Context, Ref) (io.ReadCloser, error)
 }
 
+// BlobExister is implemented by Blobstores able to check for the
+// existence of a blob without reading it.
+type BlobExister interface {
+	Exists(context.Context, Ref) (bool, error)
+}
+
+// BlobSizer is implemented by Blobstores able to return the size of a
+// blob in bytes without reading it, such as from a file stat.
+//
+// Missing blobs return a *RefError wrapping os.ErrNotExist.
+type BlobSizer interface {
+	Size(context.Context, Ref) (int64, error)
+}
+
+// BlobLister is implemented by Blobstores able to enumerate the refs
+// of all of their blobs.
+type BlobLister interface {
+	List(context.Context) ([]Ref, error)
+}
+
+// Syncer is implemented by Blobstores and Indexes which may buffer
+// writes, allowing callers to flush them to stable storage.
+type Syncer interface {
+	Sync() error
+}
+
 func NewBlobstoreFromConfig(name string, c config.Config) (Blobstore, error) {
 	if name == "" {
 		return nil, fmt.Errorf("empty blobstore name")
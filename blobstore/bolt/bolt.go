@@ -0,0 +1,291 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/util/pathutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+// MaxBlobSize is the largest blob the Blobstore will write.
+//
+// Blobs are stored whole, as a single bolt value, and are therefore
+// limited by bolt's own maximum value size. Fixity chunks are
+// expected to be a few MiB at most, so this ceiling should only be
+// reached by misuse.
+const MaxBlobSize = bolt.MaxValueSize
+
+var blobsBucket = []byte("blobs")
+
+type Config struct {
+	// Path is the bolt database file, joined to the config RootPath.
+	Path string `json:"path" validate:"required"`
+
+	// ReadOnly opens the bolt database read only, rejecting all writes
+	// with fixity.ErrReadOnly.
+	//
+	// The database file must already exist.
+	ReadOnly bool `json:"readOnly"`
+
+	// KeyPrefix stores all blobs within a nested bucket of that name,
+	// allowing multiple instances to share a single database file.
+	//
+	// The prefix is transparent to callers, refs are unchanged. Each
+	// prefix is a distinct bucket, so no prefix lists the blobs of
+	// another, including an unprefixed instance.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+
+	// NoSync skips the fsync after each write transaction.
+	//
+	// Writes are significantly faster, but blobs written shortly before
+	// a crash or power loss may be lost, and the database file may be
+	// corrupted. Only use this for stores which can be rebuilt, such as
+	// bulk imports which can be rerun.
+	NoSync bool `json:"noSync,omitempty"`
+
+	// Timeout is how long to wait for the database file lock, held by
+	// any other process with the database open, as a duration string
+	// such as "1s".
+	//
+	// Defaults to waiting indefinitely.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Blobstore implements a Fixity Blobstore within a single bolt database
+// file, keyed by blob ref.
+//
+// Useful for single file deployments, where millions of blob files
+// on disk are inconvenient.
+type Blobstore struct {
+	db        *bolt.DB
+	readOnly  bool
+	keyPrefix string
+}
+
+func New(name string, cfg config.Config) (*Blobstore, error) {
+	var c Config
+	if err := cfg.BlobstoreConfig(name, &c); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %v", err)
+	}
+
+	if err := config.Validate(c); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+
+	dbPath, err := pathutil.ExpandJoin(cfg.RootPath, c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("expandjoin: %v", err)
+	}
+
+	if dbPath == "" {
+		return nil, errors.New("rootpath and bolt path empty")
+	}
+
+	var timeout time.Duration
+	if c.Timeout != "" {
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("timeout: %v", err)
+		}
+	}
+
+	if c.ReadOnly {
+		db, err := bolt.Open(dbPath, 0600, &bolt.Options{
+			ReadOnly: true,
+			Timeout:  timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("bolt open: %v", err)
+		}
+
+		return &Blobstore{
+			db:        db,
+			readOnly:  true,
+			keyPrefix: c.KeyPrefix,
+		}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("bolt open: %v", err)
+	}
+	db.NoSync = c.NoSync
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(blobsBucket)
+		if err != nil {
+			return err
+		}
+		if c.KeyPrefix != "" {
+			_, err = bkt.CreateBucketIfNotExists([]byte(c.KeyPrefix))
+		}
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create bucket: %v", err)
+	}
+
+	return &Blobstore{
+		db:        db,
+		keyPrefix: c.KeyPrefix,
+	}, nil
+}
+
+// bucket returns the bucket holding the blobs of this instance, or nil
+// if it does not exist, as in a read only db never written to.
+func (s *Blobstore) bucket(tx *bolt.Tx) *bolt.Bucket {
+	bkt := tx.Bucket(blobsBucket)
+	if bkt == nil || s.keyPrefix == "" {
+		return bkt
+	}
+
+	return bkt.Bucket([]byte(s.keyPrefix))
+}
+
+// Sync forces an fsync of the database, which is needed to make writes
+// durable when configured with NoSync.
+func (s *Blobstore) Sync() error {
+	if s.readOnly {
+		return nil
+	}
+
+	return s.db.Sync()
+}
+
+func (s *Blobstore) Close() error {
+	return s.db.Close()
+}
+
+func (s *Blobstore) Read(_ context.Context, h fixity.Ref) (io.ReadCloser, error) {
+	if h == "" {
+		return nil, errors.New("hash cannot be empty")
+	}
+
+	var b []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bkt := s.bucket(tx)
+		if bkt == nil {
+			return os.ErrNotExist
+		}
+
+		v := bkt.Get([]byte(h))
+		if v == nil {
+			return os.ErrNotExist
+		}
+
+		// bolt values are only valid for the life of the transaction.
+		b = make([]byte, len(v))
+		copy(b, v)
+		return nil
+	})
+	if err == os.ErrNotExist {
+		return nil, &fixity.RefError{Op: "read", Ref: h, Err: err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("view: %v", err)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *Blobstore) Write(_ context.Context, b []byte) (fixity.Ref, error) {
+	if s.readOnly {
+		return "", fixity.ErrReadOnly
+	}
+
+	if len(b) > MaxBlobSize {
+		return "", fmt.Errorf("blob size %d exceeds max size %d", len(b), MaxBlobSize)
+	}
+
+	h, err := fixity.Hash(b)
+	if err != nil {
+		return "", fmt.Errorf("hash: %v", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return s.bucket(tx).Put([]byte(h), b)
+	})
+	if err != nil {
+		return "", fmt.Errorf("update: %v", err)
+	}
+
+	return h, nil
+}
+
+func (s *Blobstore) Exists(_ context.Context, h fixity.Ref) (bool, error) {
+	var exists bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if bkt := s.bucket(tx); bkt != nil {
+			exists = bkt.Get([]byte(h)) != nil
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("view: %v", err)
+	}
+
+	return exists, nil
+}
+
+func (s *Blobstore) Size(_ context.Context, h fixity.Ref) (int64, error) {
+	var size int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bkt := s.bucket(tx)
+		if bkt == nil {
+			return os.ErrNotExist
+		}
+
+		v := bkt.Get([]byte(h))
+		if v == nil {
+			return os.ErrNotExist
+		}
+
+		size = int64(len(v))
+		return nil
+	})
+	if err == os.ErrNotExist {
+		return 0, &fixity.RefError{Op: "size", Ref: h, Err: err}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("view: %v", err)
+	}
+
+	return size, nil
+}
+
+func (s *Blobstore) List(_ context.Context) ([]fixity.Ref, error) {
+	var refs []fixity.Ref
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bkt := s.bucket(tx)
+		if bkt == nil {
+			return nil
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			// a nil value is the nested bucket of a prefixed instance.
+			if v != nil {
+				refs = append(refs, fixity.Ref(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("view: %v", err)
+	}
+
+	return refs, nil
+}
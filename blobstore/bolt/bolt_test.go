@@ -0,0 +1,286 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+)
+
+func testConfig(dir, rawConfig string) config.Config {
+	return config.Config{
+		RootPath: dir,
+		BlobstoreConfigs: map[string]config.TypeConfig{
+			"test": {
+				Type:   configType,
+				Config: []byte(rawConfig),
+			},
+		},
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "fixity-bolt")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func newTestBlobstore(t *testing.T) *Blobstore {
+	bs, err := New("test", testConfig(tempDir(t), `{"path":"blobs.db"}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	t.Cleanup(func() { bs.Close() })
+
+	return bs
+}
+
+func TestBlobstore(t *testing.T) {
+	ctx := context.Background()
+	bs := newTestBlobstore(t)
+
+	blobs := []string{"foo", "bar", "baz"}
+	refs := map[fixity.Ref]string{}
+	for _, b := range blobs {
+		ref, err := bs.Write(ctx, []byte(b))
+		if err != nil {
+			t.Fatalf("write %q: %v", b, err)
+		}
+		refs[ref] = b
+	}
+
+	for ref, expect := range refs {
+		rc, err := bs.Read(ctx, ref)
+		if err != nil {
+			t.Fatalf("read %q: %v", ref, err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("readall %q: %v", ref, err)
+		}
+		if string(b) != expect {
+			t.Errorf("read %q want:%q, got:%q", ref, expect, b)
+		}
+
+		exists, err := bs.Exists(ctx, ref)
+		if err != nil {
+			t.Fatalf("exists %q: %v", ref, err)
+		}
+		if !exists {
+			t.Errorf("exists %q want:true, got:false", ref)
+		}
+
+		size, err := bs.Size(ctx, ref)
+		if err != nil {
+			t.Fatalf("size %q: %v", ref, err)
+		}
+		if size != int64(len(expect)) {
+			t.Errorf("size %q want:%d, got:%d", ref, len(expect), size)
+		}
+	}
+
+	missing, err := fixity.Hash([]byte("missing"))
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if exists, _ := bs.Exists(ctx, missing); exists {
+		t.Errorf("exists missing want:false, got:true")
+	}
+	if _, err := bs.Read(ctx, missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("read missing want:%v, got:%v", os.ErrNotExist, err)
+	}
+	if _, err := bs.Size(ctx, missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("size missing want:%v, got:%v", os.ErrNotExist, err)
+	}
+
+	listed, err := bs.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(listed) != len(refs) {
+		t.Errorf("list want:%d refs, got:%d", len(refs), len(listed))
+	}
+	for _, ref := range listed {
+		if _, ok := refs[ref]; !ok {
+			t.Errorf("list returned unexpected ref: %q", ref)
+		}
+	}
+}
+
+func TestBlobstoreKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	dir := tempDir(t)
+
+	// prefixes which are prefixes of one another, and no prefix at all,
+	// must each see only their own blobs.
+	testCases := []struct {
+		KeyPrefix string
+		Blob      string
+	}{
+		{"", "foo"},
+		{"tenant", "bar"},
+		{"tenant2", "baz"},
+	}
+
+	refs := map[string]fixity.Ref{}
+	for _, tc := range testCases {
+		rawConfig := fmt.Sprintf(`{"path":"blobs.db","keyPrefix":%q}`, tc.KeyPrefix)
+		bs, err := New("test", testConfig(dir, rawConfig))
+		if err != nil {
+			t.Fatalf("new %q: %v", tc.KeyPrefix, err)
+		}
+		ref, err := bs.Write(ctx, []byte(tc.Blob))
+		bs.Close()
+		if err != nil {
+			t.Fatalf("write %q: %v", tc.KeyPrefix, err)
+		}
+		refs[tc.KeyPrefix] = ref
+	}
+
+	for _, tc := range testCases {
+		rawConfig := fmt.Sprintf(`{"path":"blobs.db","keyPrefix":%q}`, tc.KeyPrefix)
+		bs, err := New("test", testConfig(dir, rawConfig))
+		if err != nil {
+			t.Fatalf("new %q: %v", tc.KeyPrefix, err)
+		}
+
+		listed, err := bs.List(ctx)
+		if err != nil {
+			bs.Close()
+			t.Fatalf("list %q: %v", tc.KeyPrefix, err)
+		}
+		if len(listed) != 1 || listed[0] != refs[tc.KeyPrefix] {
+			t.Errorf("list %q want:[%s], got:%v", tc.KeyPrefix, refs[tc.KeyPrefix], listed)
+		}
+
+		for prefix, ref := range refs {
+			exists, err := bs.Exists(ctx, ref)
+			if err != nil {
+				bs.Close()
+				t.Fatalf("exists %q: %v", tc.KeyPrefix, err)
+			}
+			if expect := prefix == tc.KeyPrefix; exists != expect {
+				t.Errorf("exists %q in %q want:%v, got:%v", prefix, tc.KeyPrefix, expect, exists)
+			}
+		}
+		bs.Close()
+	}
+}
+
+func TestBlobstoreReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dir := tempDir(t)
+
+	bs, err := New("test", testConfig(dir, `{"path":"blobs.db"}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	ref, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := bs.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	ro, err := New("test", testConfig(dir, `{"path":"blobs.db","readOnly":true}`))
+	if err != nil {
+		t.Fatalf("new readonly: %v", err)
+	}
+	defer ro.Close()
+
+	if _, err := ro.Write(ctx, []byte("bar")); err != fixity.ErrReadOnly {
+		t.Errorf("write want:%v, got:%v", fixity.ErrReadOnly, err)
+	}
+
+	rc, err := ro.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if string(b) != "foo" {
+		t.Errorf("read want:%q, got:%q", "foo", b)
+	}
+}
+
+func TestBlobstoreNoSync(t *testing.T) {
+	ctx := context.Background()
+
+	bs, err := New("test", testConfig(tempDir(t), `{"path":"blobs.db","noSync":true,"timeout":"1s"}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer bs.Close()
+
+	if !bs.db.NoSync {
+		t.Errorf("db nosync want:true, got:false")
+	}
+
+	ref, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	rc, err := bs.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if string(b) != "foo" {
+		t.Errorf("read want:foo, got:%q", b)
+	}
+}
+
+func TestBlobstoreSync(t *testing.T) {
+	ctx := context.Background()
+	c := testConfig(tempDir(t), `{"path":"blobs.db","noSync":true}`)
+
+	bs, err := New("test", c)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	ref, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if err := bs.Sync(); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if err := bs.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := New("test", c)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	exists, err := reopened.Exists(ctx, ref)
+	if err != nil {
+		t.Fatalf("exists: %v", err)
+	}
+	if !exists {
+		t.Errorf("synced blob missing after reopen")
+	}
+}
@@ -0,0 +1,17 @@
+package bolt
+
+import (
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+)
+
+const configType = "bolt"
+
+func init() {
+	fixity.RegisterBlobstore(configType, fixity.BlobstoreConstructorFunc(Constructor))
+	config.RegisterConfigType(configType, Config{})
+}
+
+func Constructor(n string, c config.Config) (fixity.Blobstore, error) {
+	return New(n, c)
+}
@@ -9,6 +9,7 @@ const configType = "disk"
 
 func init() {
 	fixity.RegisterBlobstore(configType, fixity.BlobstoreConstructorFunc(Constructor))
+	config.RegisterConfigType(configType, Config{})
 }
 
 func Constructor(n string, c config.Config) (fixity.Blobstore, error) {
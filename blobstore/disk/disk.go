@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/leeola/fixity"
@@ -17,9 +19,55 @@ import (
 
 const bsDir = "blobs"
 
+// tmpSuffix is appended to the path of a blob while it is being written,
+// so that only complete, verified blobs exist at their ref path.
+const tmpSuffix = ".tmp"
+
+// writeFile is a seam allowing tests to simulate faulty disks.
+var writeFile = ioutil.WriteFile
+
 type Config struct {
-	Path string `json:"path"`
+	// Path is the directory blobs are stored within, relative to the
+	// config RootPath.
+	Path string `json:"path" validate:"required"`
 	Flat bool   `json:"flat"`
+
+	// ReadOnly rejects all writes with fixity.ErrReadOnly, allowing
+	// archived data to be served without risk of modification.
+	ReadOnly bool `json:"readOnly"`
+
+	// KeyPrefix is a subdirectory of Path that all blobs are stored
+	// within, allowing multiple instances to share a single Path.
+	//
+	// The prefix must be a clean relative path within Path, such as
+	// "a" or "a/b", never absolute or escaping Path with "..".
+	//
+	// The prefix is transparent to callers, refs are unchanged. All
+	// instances sharing a Path should use distinct prefixes, as an
+	// unprefixed List would walk into prefixed directories.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+
+	// VerifyOnWrite re-reads and re-hashes every blob after writing it,
+	// returning an error if the stored bytes do not match the ref.
+	//
+	// This catches silent disk corruption at write time, at the cost of
+	// an additional read and hash of every written blob, roughly
+	// doubling the IO and CPU cost of writes.
+	VerifyOnWrite bool `json:"verifyOnWrite,omitempty"`
+
+	// MaxOpenFiles bounds the number of blob files open for reading at
+	// once. Reads beyond the limit block until an open reader is closed,
+	// rather than failing with "too many open files".
+	//
+	// Defaults to unlimited.
+	MaxOpenFiles int `json:"maxOpenFiles,omitempty" validate:"min=0"`
+
+	// ReadBufferSize, if set, buffers read blob files with a buffer of
+	// this many bytes, reducing syscalls for callers making many small
+	// reads of large blobs.
+	//
+	// Defaults to unbuffered.
+	ReadBufferSize int `json:"readBufferSize,omitempty" validate:"min=0"`
 }
 
 // Blobstore implements a Fixity Blobstore for an simple Filesystem.
@@ -28,9 +76,18 @@ type Config struct {
 // side effects are mostly harmless. Safe readers of partial writes
 // should verify data regardless.
 type Blobstore struct {
-	mu   sync.Mutex
-	path string
-	flat bool
+	mu            sync.Mutex
+	path          string
+	flat          bool
+	readOnly      bool
+	verifyOnWrite bool
+	readBufSize   int
+
+	// openGate, if not nil, holds a token for every open read file.
+	openGate chan struct{}
+
+	inflightMu sync.Mutex
+	inflight   map[fixity.Ref]*writeCall
 }
 
 func New(name string, cfg config.Config) (*Blobstore, error) {
@@ -39,7 +96,17 @@ func New(name string, cfg config.Config) (*Blobstore, error) {
 		return nil, fmt.Errorf("unmarshal config: %v", err)
 	}
 
-	rootPath, err := pathutil.ExpandJoin(cfg.RootPath, c.Path)
+	if err := config.Validate(c); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+
+	if c.KeyPrefix != "" {
+		if err := validKeyPrefix(c.KeyPrefix); err != nil {
+			return nil, fmt.Errorf("keyprefix %q: %v", c.KeyPrefix, err)
+		}
+	}
+
+	rootPath, err := pathutil.ExpandJoin(cfg.RootPath, c.Path, c.KeyPrefix)
 	if err != nil {
 		return nil, fmt.Errorf("expandjoin: %v", err)
 	}
@@ -48,55 +115,282 @@ func New(name string, cfg config.Config) (*Blobstore, error) {
 		return nil, errors.New("rootpath and disk path empty")
 	}
 
-	if err := os.MkdirAll(rootPath, 0755); err != nil {
-		return nil, err
+	if !c.ReadOnly {
+		if err := os.MkdirAll(rootPath, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	var openGate chan struct{}
+	if c.MaxOpenFiles > 0 {
+		openGate = make(chan struct{}, c.MaxOpenFiles)
 	}
 
 	return &Blobstore{
-		path: rootPath,
-		flat: c.Flat,
+		path:          rootPath,
+		flat:          c.Flat,
+		readOnly:      c.ReadOnly,
+		verifyOnWrite: c.VerifyOnWrite,
+		readBufSize:   c.ReadBufferSize,
+		openGate:      openGate,
+		inflight:      map[fixity.Ref]*writeCall{},
 	}, nil
 }
 
-func (s *Blobstore) Read(_ context.Context, h fixity.Ref) (io.ReadCloser, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// validKeyPrefix errors if the prefix is not a clean relative path,
+// as it would otherwise be able to place blobs outside of Path.
+func validKeyPrefix(prefix string) error {
+	switch {
+	case filepath.IsAbs(prefix):
+		return errors.New("must be relative")
+	case filepath.Clean(prefix) != prefix:
+		return errors.New("must be a clean path")
+	case prefix == "." || prefix == ".." ||
+		strings.HasPrefix(prefix, ".."+string(filepath.Separator)):
+		return errors.New("must be within path")
+	}
 
+	return nil
+}
+
+func (s *Blobstore) Read(ctx context.Context, h fixity.Ref) (io.ReadCloser, error) {
 	if h == "" {
 		return nil, errors.New("hash cannot be empty")
 	}
 
+	// acquire before locking, as a full gate is only freed by closing
+	// a reader, which must not wait on the lock.
+	if s.openGate != nil {
+		select {
+		case s.openGate <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	p := s.pathHash(string(h))
 
-	rc, err := os.Open(p)
+	f, err := os.Open(p)
+	if err != nil {
+		s.releaseOpen()
+	}
 	if os.IsNotExist(err) {
-		return nil, err
+		return nil, &fixity.RefError{Op: "read", Ref: h, Err: err}
 	}
 	if err != nil {
 		return nil, fmt.Errorf("open: %v", err)
 	}
 
+	var rc io.ReadCloser = f
+	if s.openGate != nil {
+		rc = &gatedFile{File: f, release: s.releaseOpen}
+	}
+
+	if s.readBufSize > 0 {
+		rc = bufferedFile{
+			Reader: bufio.NewReaderSize(rc, s.readBufSize),
+			Closer: rc,
+		}
+	}
+
 	return rc, nil
 }
 
+// bufferedFile reads from a buffer of the file, closing the file once
+// closed.
+type bufferedFile struct {
+	*bufio.Reader
+	io.Closer
+}
+
+func (s *Blobstore) releaseOpen() {
+	if s.openGate != nil {
+		<-s.openGate
+	}
+}
+
+// gatedFile releases its open file token once closed.
+type gatedFile struct {
+	*os.File
+	once    sync.Once
+	release func()
+}
+
+func (f *gatedFile) Close() error {
+	err := f.File.Close()
+	f.once.Do(f.release)
+	return err
+}
+
 func (s *Blobstore) Write(_ context.Context, b []byte) (fixity.Ref, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	if s.readOnly {
+		return "", fixity.ErrReadOnly
+	}
 
 	h, err := fixity.Hash(b)
 	if err != nil {
 		return "", fmt.Errorf("hash: %v", err)
 	}
 
+	// coalesce concurrent writes of the same blob, such as many
+	// goroutines syncing the same content, into a single disk write.
+	s.inflightMu.Lock()
+	if c, ok := s.inflight[h]; ok {
+		s.inflightMu.Unlock()
+		c.wg.Wait()
+		if c.err != nil {
+			return "", c.err
+		}
+		return h, nil
+	}
+	c := &writeCall{}
+	c.wg.Add(1)
+	s.inflight[h] = c
+	s.inflightMu.Unlock()
+
+	c.err = s.writeBlob(h, b)
+	c.wg.Done()
+
+	s.inflightMu.Lock()
+	delete(s.inflight, h)
+	s.inflightMu.Unlock()
+
+	if c.err != nil {
+		return "", c.err
+	}
+
+	return h, nil
+}
+
+// writeCall is an in flight write, shared by concurrent writers of the
+// same blob.
+type writeCall struct {
+	wg  sync.WaitGroup
+	err error
+}
+
+func (s *Blobstore) writeBlob(h fixity.Ref, b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	p := s.pathHash(string(h))
 
 	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
-		return "", fmt.Errorf("mkdirall: %v", err)
+		return fmt.Errorf("mkdirall: %v", err)
 	}
 
-	if err := ioutil.WriteFile(p, b, 0644); err != nil {
-		return "", fmt.Errorf("writefile: %v", err)
+	// written aside and renamed into place, so a failed write or
+	// verification never leaves a partial or corrupt blob at the ref
+	// path. Concurrent writes of a ref are coalesced, and s.mu is held,
+	// so the temp path is not shared.
+	tmp := p + tmpSuffix
+
+	if err := writeFile(tmp, b, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writefile: %v", err)
 	}
 
-	return h, nil
+	if s.verifyOnWrite {
+		if err := verifyFile(tmp, h); err != nil {
+			os.Remove(tmp)
+			// %w, allowing errors.As to retrieve the mismatched refs.
+			return fmt.Errorf("verify: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename: %v", err)
+	}
+
+	return nil
+}
+
+// verifyFile re-reads the file at p and confirms it hashes to h.
+func verifyFile(p string, h fixity.Ref) error {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return fmt.Errorf("readfile: %v", err)
+	}
+
+	got, err := fixity.Hash(b)
+	if err != nil {
+		return fmt.Errorf("hash: %v", err)
+	}
+
+	if got != h {
+		return &fixity.RefMismatchError{Expected: h, Actual: got}
+	}
+
+	return nil
+}
+
+func (s *Blobstore) Exists(_ context.Context, h fixity.Ref) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := os.Stat(s.pathHash(string(h)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat: %v", err)
+	}
+
+	return true, nil
+}
+
+func (s *Blobstore) Size(_ context.Context, h fixity.Ref) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.pathHash(string(h)))
+	if os.IsNotExist(err) {
+		return 0, &fixity.RefError{Op: "size", Ref: h, Err: err}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("stat: %v", err)
+	}
+
+	return info.Size(), nil
+}
+
+func (s *Blobstore) List(_ context.Context) ([]fixity.Ref, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var refs []fixity.Ref
+	err := filepath.Walk(s.path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// temp files are incomplete writes, possibly left by a crash.
+		if info.IsDir() || strings.HasSuffix(p, tmpSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.path, p)
+		if err != nil {
+			return err
+		}
+
+		ref, err := s.hashPath(rel)
+		if err != nil {
+			return fmt.Errorf("hashpath %s: %v", rel, err)
+		}
+
+		refs = append(refs, ref)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("walk: %v", err)
+	}
+
+	return refs, nil
 }
@@ -3,9 +3,12 @@ package disk
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	base58 "github.com/jbenet/go-base58"
+	"github.com/leeola/fixity"
 )
 
 func (s *Blobstore) pathHash(h string) string {
@@ -32,3 +35,16 @@ func (s *Blobstore) pathHash(h string) string {
 
 	return filepath.Join(s.path, p)
 }
+
+// hashPath is the inverse of pathHash, returning the ref for the given
+// path relative to the blobstore path.
+func (s *Blobstore) hashPath(rel string) (fixity.Ref, error) {
+	h := strings.Replace(filepath.ToSlash(rel), "/", "", -1)
+
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		return "", fmt.Errorf("decodestring: %v", err)
+	}
+
+	return fixity.Ref(base58.Encode(b)), nil
+}
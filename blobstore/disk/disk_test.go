@@ -0,0 +1,533 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+)
+
+func testConfig(dir, rawConfig string) config.Config {
+	return config.Config{
+		RootPath: dir,
+		BlobstoreConfigs: map[string]config.TypeConfig{
+			"test": {
+				Type:   configType,
+				Config: []byte(rawConfig),
+			},
+		},
+	}
+}
+
+func TestBlobstoreKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bs, err := New("test", testConfig(dir, `{"path":"blobs","keyPrefix":"instance-a"}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	ref, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	expectRef, err := fixity.Hash([]byte("foo"))
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if ref != expectRef {
+		t.Errorf("ref want:%s, got:%s", expectRef, ref)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "blobs", "instance-a")); err != nil {
+		t.Errorf("prefix dir stat: %v", err)
+	}
+
+	rc, err := bs.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if string(b) != "foo" {
+		t.Errorf("read want:%q, got:%q", "foo", b)
+	}
+
+	refs, err := bs.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(refs) != 1 || refs[0] != ref {
+		t.Errorf("list want:[%s], got:%v", ref, refs)
+	}
+}
+
+func TestNewRequiresPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = New("test", testConfig(dir, `{"flat":true}`))
+	if err == nil {
+		t.Fatalf("new without path want error")
+	}
+	if !strings.Contains(err.Error(), "path: is required") {
+		t.Errorf("new err want path required, got:%v", err)
+	}
+}
+
+func TestNewKeyPrefix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		KeyPrefix string
+		Valid     bool
+	}{
+		{"tenant", true},
+		{"tenant/a", true},
+		{"..", false},
+		{"../x", false},
+		{"a/../../x", false},
+		{"/abs", false},
+		{"a/", false},
+		{"./a", false},
+		{".", false},
+	}
+
+	for _, tc := range testCases {
+		rawConfig := fmt.Sprintf(`{"path":"blobs","keyPrefix":%q}`, tc.KeyPrefix)
+		_, err := New("test", testConfig(dir, rawConfig))
+		if tc.Valid && err != nil {
+			t.Errorf("new %q want no error, got:%v", tc.KeyPrefix, err)
+		}
+		if !tc.Valid && err == nil {
+			t.Errorf("new %q want error, got none", tc.KeyPrefix)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "x")); !os.IsNotExist(err) {
+		t.Errorf("escaped prefix dir want not exist, got:%v", err)
+	}
+}
+
+func TestRedactedConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := testConfig(dir, `{"path":"blobs","keyPrefix":"a","maxOpenFiles":8}`)
+	rc, err := c.Redacted()
+	if err != nil {
+		t.Fatalf("redacted: %v", err)
+	}
+
+	// disk configs have no secrets, so a redacted config, such as one
+	// saved by fixi config save, must still construct the blobstore.
+	var want, got Config
+	if err := c.BlobstoreConfig("test", &want); err != nil {
+		t.Fatalf("blobstoreconfig: %v", err)
+	}
+	if err := rc.BlobstoreConfig("test", &got); err != nil {
+		t.Fatalf("redacted blobstoreconfig: %v", err)
+	}
+	if got != want {
+		t.Errorf("redacted config want:%+v, got:%+v", want, got)
+	}
+
+	if _, err := New("test", rc); err != nil {
+		t.Errorf("new from redacted: %v", err)
+	}
+}
+
+func TestBlobstoreReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bs, err := New("test", testConfig(dir, `{"path":"blobs","readOnly":true}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if _, err := bs.Write(ctx, []byte("foo")); err != fixity.ErrReadOnly {
+		t.Errorf("write want:%v, got:%v", fixity.ErrReadOnly, err)
+	}
+
+	var files []string
+	err = filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("files want none, got:%v", files)
+	}
+}
+
+func TestBlobstoreVerifyOnWrite(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bs, err := New("test", testConfig(dir, `{"path":"blobs","verifyOnWrite":true}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if _, err := bs.Write(ctx, []byte("foo")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	defer func() { writeFile = ioutil.WriteFile }()
+	writeFile = func(p string, b []byte, perm os.FileMode) error {
+		corrupt := append([]byte{}, b...)
+		corrupt[0] ^= 0xff
+		return ioutil.WriteFile(p, corrupt, perm)
+	}
+
+	_, err = bs.Write(ctx, []byte("bar"))
+	if !errors.Is(err, fixity.ErrRefMismatch) {
+		t.Fatalf("corrupted write want:%v, got:%v", fixity.ErrRefMismatch, err)
+	}
+
+	var mismatch *fixity.RefMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("corrupted write want RefMismatchError, got:%T", err)
+	}
+	if expected, _ := fixity.Hash([]byte("bar")); mismatch.Expected != expected {
+		t.Errorf("mismatch expected want:%s, got:%s", expected, mismatch.Expected)
+	}
+
+	// the corrupt blob must not be stored at the ref.
+	exists, err := bs.Exists(ctx, mismatch.Expected)
+	if err != nil {
+		t.Fatalf("exists: %v", err)
+	}
+	if exists {
+		t.Errorf("corrupted write want ref not to exist")
+	}
+
+	refs, err := bs.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(refs) != 1 {
+		t.Errorf("list want only the first write, got:%v", refs)
+	}
+}
+
+func TestBlobstoreMaxOpenFiles(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	const maxOpen = 2
+	bs, err := New("test", testConfig(dir, `{"path":"blobs","maxOpenFiles":2}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	ref, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var (
+		wg            sync.WaitGroup
+		open, maxSeen int32
+		errs          = make(chan error, 20)
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rc, err := bs.Read(ctx, ref)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			n := atomic.AddInt32(&open, 1)
+			for {
+				m := atomic.LoadInt32(&maxSeen)
+				if n <= m || atomic.CompareAndSwapInt32(&maxSeen, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&open, -1)
+
+			if err := rc.Close(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if maxSeen > maxOpen {
+		t.Errorf("max open want:<=%d, got:%d", maxOpen, maxSeen)
+	}
+
+	// a full gate blocks reads until the context is done.
+	var held []io.ReadCloser
+	for i := 0; i < maxOpen; i++ {
+		rc, err := bs.Read(ctx, ref)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		held = append(held, rc)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := bs.Read(timeoutCtx, ref); err != context.DeadlineExceeded {
+		t.Errorf("read of full gate want:%v, got:%v", context.DeadlineExceeded, err)
+	}
+
+	for _, rc := range held {
+		rc.Close()
+	}
+}
+
+func TestBlobstoreCoalescedWrites(t *testing.T) {
+	ctx := context.Background()
+	defer func() { writeFile = ioutil.WriteFile }()
+
+	expect, _ := fixity.Hash([]byte("foo"))
+
+	// a failed write must fail every coalesced writer, without a ref.
+	testCases := []struct {
+		WriteErr  error
+		ExpectRef fixity.Ref
+	}{
+		{nil, expect},
+		{errors.New("disk full"), ""},
+	}
+
+	for _, tc := range testCases {
+		dir, err := ioutil.TempDir("", "fixity-disk")
+		if err != nil {
+			t.Fatalf("tempdir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		bs, err := New("test", testConfig(dir, `{"path":"blobs"}`))
+		if err != nil {
+			t.Fatalf("new: %v", err)
+		}
+
+		var (
+			writes  int32
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+		writeFile = func(p string, b []byte, perm os.FileMode) error {
+			if atomic.AddInt32(&writes, 1) == 1 {
+				close(started)
+			}
+			// hold the first write open until all writers are waiting on it.
+			<-release
+			if tc.WriteErr != nil {
+				return tc.WriteErr
+			}
+			return ioutil.WriteFile(p, b, perm)
+		}
+
+		const writers = 10
+		var (
+			wg   sync.WaitGroup
+			refs = make([]fixity.Ref, writers)
+			errs = make([]error, writers)
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			refs[0], errs[0] = bs.Write(ctx, []byte("foo"))
+		}()
+		<-started
+
+		for i := 1; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				refs[i], errs[i] = bs.Write(ctx, []byte("foo"))
+			}(i)
+		}
+
+		// allow the remaining writers to join the in flight write.
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if writes != 1 {
+			t.Errorf("%v: file writes want:1, got:%d", tc.WriteErr, writes)
+		}
+
+		for i := range refs {
+			if tc.WriteErr == nil && errs[i] != nil {
+				t.Errorf("writer %d: %v", i, errs[i])
+			}
+			if tc.WriteErr != nil && (errs[i] == nil || !strings.Contains(errs[i].Error(), tc.WriteErr.Error())) {
+				t.Errorf("writer %d err want:%v, got:%v", i, tc.WriteErr, errs[i])
+			}
+			if refs[i] != tc.ExpectRef {
+				t.Errorf("%v: writer %d ref want:%q, got:%q", tc.WriteErr, i, tc.ExpectRef, refs[i])
+			}
+		}
+	}
+}
+
+func TestBlobstoreReadBufferSize(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bs, err := New("test", testConfig(dir, `{"path":"blobs","readBufferSize":16}`))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	ref, err := bs.Write(ctx, content)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	rc, err := bs.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	bf, ok := rc.(bufferedFile)
+	if !ok {
+		t.Fatalf("read want bufferedFile, got:%T", rc)
+	}
+	if bf.Size() != 16 {
+		t.Errorf("buffer size want:16, got:%d", bf.Size())
+	}
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if string(b) != string(content) {
+		t.Errorf("read content mismatch")
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	f, ok := bf.Closer.(*os.File)
+	if !ok {
+		t.Fatalf("closer want *os.File, got:%T", bf.Closer)
+	}
+	if _, err := f.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("underlying file want closed, got:%v", err)
+	}
+}
+
+func benchmarkBlobstoreRead(b *testing.B, readBufferSize int) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-disk")
+	if err != nil {
+		b.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bs, err := New("test", testConfig(dir,
+		fmt.Sprintf(`{"path":"blobs","readBufferSize":%d}`, readBufferSize)))
+	if err != nil {
+		b.Fatalf("new: %v", err)
+	}
+
+	ref, err := bs.Write(ctx, make([]byte, 8<<20))
+	if err != nil {
+		b.Fatalf("write: %v", err)
+	}
+
+	// small reads, as made by decoders of streamed content.
+	p := make([]byte, 512)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rc, err := bs.Read(ctx, ref)
+		if err != nil {
+			b.Fatalf("read: %v", err)
+		}
+		for {
+			if _, err := rc.Read(p); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatalf("read: %v", err)
+			}
+		}
+		rc.Close()
+	}
+}
+
+func BenchmarkBlobstoreReadUnbuffered(b *testing.B) { benchmarkBlobstoreRead(b, 0) }
+func BenchmarkBlobstoreRead64KiB(b *testing.B)      { benchmarkBlobstoreRead(b, 64<<10) }
@@ -27,3 +27,205 @@ func ReadAndUnmarshal(ctx context.Context, r fixity.BlobReader, ref fixity.Ref,
 
 	return nil
 }
+
+// Describe reads the blob and returns a typed description of it,
+// including any blobs it references.
+func Describe(ctx context.Context, r fixity.BlobReader, ref fixity.Ref) (fixity.BlobDescription, error) {
+	rc, err := r.Read(ctx, ref)
+	if err != nil {
+		return fixity.BlobDescription{}, fmt.Errorf("blobstore read: %v", err)
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return fixity.BlobDescription{}, fmt.Errorf("readall: %v", err)
+	}
+
+	// the zero value of fixity.Schema.SchemaType == fixity.BlobTypeSchemaless,
+	// so failing to unmarshal is considered schemaless.
+	var schema fixity.Schema
+	_ = json.Unmarshal(b, &schema)
+
+	desc := fixity.BlobDescription{
+		Ref:  ref,
+		Type: schema.SchemaType,
+		Size: int64(len(b)),
+	}
+
+	switch schema.SchemaType {
+	case fixity.BlobTypeParts:
+		var parts fixity.PartsSchema
+		if err := json.Unmarshal(b, &parts); err != nil {
+			return fixity.BlobDescription{}, fmt.Errorf("unmarshal parts: %v", err)
+		}
+		desc.Refs = partsRefs(parts)
+	case fixity.BlobTypeData:
+		var data fixity.DataSchema
+		if err := json.Unmarshal(b, &data); err != nil {
+			return fixity.BlobDescription{}, fmt.Errorf("unmarshal data: %v", err)
+		}
+		desc.Refs = partsRefs(data.PartsSchema)
+	case fixity.BlobTypeMutation:
+		var mutation fixity.Mutation
+		if err := json.Unmarshal(b, &mutation); err != nil {
+			return fixity.BlobDescription{}, fmt.Errorf("unmarshal mutation: %v", err)
+		}
+		if mutation.ValuesSchema != "" {
+			desc.Refs = append(desc.Refs, mutation.ValuesSchema)
+		}
+		if mutation.DataSchema != "" {
+			desc.Refs = append(desc.Refs, mutation.DataSchema)
+		}
+	}
+
+	return desc, nil
+}
+
+func partsRefs(p fixity.PartsSchema) []fixity.Ref {
+	refs := append([]fixity.Ref{}, p.Parts...)
+	if p.MoreParts != nil {
+		refs = append(refs, *p.MoreParts)
+	}
+	return refs
+}
+
+// Diff lists both blobstores, returning the refs present in a but
+// missing in b, and those present in b but missing in a.
+func Diff(ctx context.Context, a, b fixity.BlobLister) (missingInB, missingInA []fixity.Ref, err error) {
+	aRefs, err := a.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list a: %v", err)
+	}
+
+	bRefs, err := b.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list b: %v", err)
+	}
+
+	return refsDifference(aRefs, bRefs), refsDifference(bRefs, aRefs), nil
+}
+
+// refsDifference returns the refs of a which are not in b.
+func refsDifference(a, b []fixity.Ref) []fixity.Ref {
+	inB := make(map[fixity.Ref]struct{}, len(b))
+	for _, ref := range b {
+		inB[ref] = struct{}{}
+	}
+
+	var diff []fixity.Ref
+	for _, ref := range a {
+		if _, ok := inB[ref]; !ok {
+			diff = append(diff, ref)
+		}
+	}
+
+	return diff
+}
+
+// ChunkRefs returns the refs of every chunk of the data blob, in order,
+// following MoreParts.
+func ChunkRefs(ctx context.Context, r fixity.BlobReader, dataRef fixity.Ref) ([]fixity.Ref, error) {
+	var data fixity.DataSchema
+	if err := ReadAndUnmarshal(ctx, r, dataRef, &data); err != nil {
+		return nil, fmt.Errorf("read data %q: %v", dataRef, err)
+	}
+
+	refs := append([]fixity.Ref{}, data.Parts...)
+	next := data.MoreParts
+	visited := map[fixity.Ref]bool{}
+	for next != nil {
+		if visited[*next] {
+			return nil, fmt.Errorf("%w: parts %q", fixity.ErrChainCycle, *next)
+		}
+		visited[*next] = true
+
+		var parts fixity.PartsSchema
+		if err := ReadAndUnmarshal(ctx, r, *next, &parts); err != nil {
+			return nil, fmt.Errorf("read parts %q: %v", *next, err)
+		}
+		refs = append(refs, parts.Parts...)
+		next = parts.MoreParts
+	}
+
+	return refs, nil
+}
+
+// DedupReport compares the chunks of two data blobs.
+type DedupReport struct {
+	// AChunks and BChunks are the number of unique chunks of each blob.
+	AChunks int `json:"aChunks"`
+	BChunks int `json:"bChunks"`
+
+	// SharedChunks is the number of unique chunks in both blobs.
+	SharedChunks int `json:"sharedChunks"`
+
+	// Overlap is SharedChunks over the unique chunks of both blobs
+	// combined, where 1 means the chunk sets are identical.
+	Overlap float64 `json:"overlap"`
+
+	// IdenticalContent is true if both blobs have the same size and
+	// checksum, and are therefore expected to have identical chunks.
+	IdenticalContent bool `json:"identicalContent"`
+
+	// Nondeterministic is true if the content is identical but the
+	// chunks are not, indicating the chunker did not produce the same
+	// boundaries for the same input.
+	Nondeterministic bool `json:"nondeterministic"`
+}
+
+// CompareChunks reports how many chunks the two data blobs share,
+// confirming whether deduplication is occurring between them.
+func CompareChunks(ctx context.Context, r fixity.BlobReader, aRef, bRef fixity.Ref) (DedupReport, error) {
+	var a, b fixity.DataSchema
+	if err := ReadAndUnmarshal(ctx, r, aRef, &a); err != nil {
+		return DedupReport{}, fmt.Errorf("read a %q: %v", aRef, err)
+	}
+	if err := ReadAndUnmarshal(ctx, r, bRef, &b); err != nil {
+		return DedupReport{}, fmt.Errorf("read b %q: %v", bRef, err)
+	}
+
+	aChunks, err := ChunkRefs(ctx, r, aRef)
+	if err != nil {
+		return DedupReport{}, fmt.Errorf("chunks a: %v", err)
+	}
+	bChunks, err := ChunkRefs(ctx, r, bRef)
+	if err != nil {
+		return DedupReport{}, fmt.Errorf("chunks b: %v", err)
+	}
+
+	aSet, bSet := refSet(aChunks), refSet(bChunks)
+	var shared int
+	for ref := range aSet {
+		if _, ok := bSet[ref]; ok {
+			shared++
+		}
+	}
+
+	report := DedupReport{
+		AChunks:      len(aSet),
+		BChunks:      len(bSet),
+		SharedChunks: shared,
+		IdenticalContent: a.Size == b.Size && a.Checksum == b.Checksum &&
+			a.ChecksumAlgorithm == b.ChecksumAlgorithm,
+	}
+
+	if union := len(aSet) + len(bSet) - shared; union > 0 {
+		report.Overlap = float64(shared) / float64(union)
+	} else {
+		// two empty blobs are trivially identical.
+		report.Overlap = 1
+	}
+
+	report.Nondeterministic = report.IdenticalContent && report.Overlap != 1
+
+	return report, nil
+}
+
+func refSet(refs []fixity.Ref) map[fixity.Ref]struct{} {
+	set := make(map[fixity.Ref]struct{}, len(refs))
+	for _, ref := range refs {
+		set[ref] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,242 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore/memory"
+	"github.com/leeola/fixity/util/wutil"
+	"github.com/leeola/fixity/value"
+)
+
+func TestDescribe(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	chunkRef, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write chunk: %v", err)
+	}
+
+	refs, _, err := wutil.WriteData(ctx, bs, []fixity.Ref{chunkRef}, 3, "", fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+	dataRef := refs[len(refs)-1]
+
+	valuesRef, err := wutil.WriteValues(ctx, bs, fixity.Values{"foo": value.String("bar")})
+	if err != nil {
+		t.Fatalf("writevalues: %v", err)
+	}
+
+	mutationRef, err := wutil.MarshalAndWrite(ctx, bs, fixity.Mutation{
+		Schema:       fixity.Schema{SchemaType: fixity.BlobTypeMutation},
+		ID:           "foo",
+		ValuesSchema: valuesRef,
+		DataSchema:   dataRef,
+	})
+	if err != nil {
+		t.Fatalf("marshalandwrite mutation: %v", err)
+	}
+
+	testCases := []struct {
+		Ref        fixity.Ref
+		ExpectType fixity.BlobType
+		ExpectRefs []fixity.Ref
+	}{
+		{
+			Ref:        chunkRef,
+			ExpectType: fixity.BlobTypeSchemaless,
+		},
+		{
+			Ref:        dataRef,
+			ExpectType: fixity.BlobTypeData,
+			ExpectRefs: []fixity.Ref{chunkRef},
+		},
+		{
+			Ref:        valuesRef,
+			ExpectType: fixity.BlobTypeValues,
+		},
+		{
+			Ref:        mutationRef,
+			ExpectType: fixity.BlobTypeMutation,
+			ExpectRefs: []fixity.Ref{valuesRef, dataRef},
+		},
+	}
+	for _, testCase := range testCases {
+		desc, err := Describe(ctx, bs, testCase.Ref)
+		if err != nil {
+			t.Fatalf("describe %q: %v", testCase.Ref, err)
+		}
+		if desc.Type != testCase.ExpectType {
+			t.Errorf("%q type want:%s, got:%s", testCase.Ref, testCase.ExpectType, desc.Type)
+		}
+		if !reflect.DeepEqual(desc.Refs, testCase.ExpectRefs) {
+			t.Errorf("%q refs want:%v, got:%v", testCase.Ref, testCase.ExpectRefs, desc.Refs)
+		}
+		if desc.Size == 0 {
+			t.Errorf("%q size want nonzero, got 0", testCase.Ref)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	a, b := memory.New(), memory.New()
+
+	for _, s := range []string{"foo", "bar"} {
+		if _, err := a.Write(ctx, []byte(s)); err != nil {
+			t.Fatalf("write a: %v", err)
+		}
+	}
+	for _, s := range []string{"foo", "baz"} {
+		if _, err := b.Write(ctx, []byte(s)); err != nil {
+			t.Fatalf("write b: %v", err)
+		}
+	}
+
+	barRef, _ := fixity.Hash([]byte("bar"))
+	bazRef, _ := fixity.Hash([]byte("baz"))
+
+	missingInB, missingInA, err := Diff(ctx, a, b)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+
+	if want := []fixity.Ref{barRef}; !reflect.DeepEqual(missingInB, want) {
+		t.Errorf("missing in b want:%v, got:%v", want, missingInB)
+	}
+	if want := []fixity.Ref{bazRef}; !reflect.DeepEqual(missingInA, want) {
+		t.Errorf("missing in a want:%v, got:%v", want, missingInA)
+	}
+}
+
+func TestCompareChunks(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	writeData := func(chunks ...string) fixity.Ref {
+		var (
+			refs []fixity.Ref
+			size int64
+			all  string
+		)
+		for _, c := range chunks {
+			ref, err := bs.Write(ctx, []byte(c))
+			if err != nil {
+				t.Fatalf("write chunk: %v", err)
+			}
+			refs = append(refs, ref)
+			size += int64(len(c))
+			all += c
+		}
+
+		// the checksum only needs to identify the content for the report.
+		refs, _, err := wutil.WriteData(ctx, bs, refs, size, all, fixity.DefaultMultihashName)
+		if err != nil {
+			t.Fatalf("writedata: %v", err)
+		}
+		return refs[len(refs)-1]
+	}
+
+	testCases := []struct {
+		Name             string
+		A, B             fixity.Ref
+		Overlap          float64
+		Nondeterministic bool
+	}{
+		{
+			Name:    "identical",
+			A:       writeData("foo", "bar"),
+			B:       writeData("foo", "bar"),
+			Overlap: 1,
+		},
+		{
+			Name:    "partial",
+			A:       writeData("foo", "bar"),
+			B:       writeData("foo", "baz"),
+			Overlap: 1.0 / 3.0,
+		},
+		{
+			Name:             "nondeterministic",
+			A:                writeData("foo", "bar"),
+			B:                writeData("fo", "obar"),
+			Overlap:          0,
+			Nondeterministic: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		report, err := CompareChunks(ctx, bs, tc.A, tc.B)
+		if err != nil {
+			t.Fatalf("%s comparechunks: %v", tc.Name, err)
+		}
+		if report.Overlap != tc.Overlap {
+			t.Errorf("%s overlap want:%f, got:%f", tc.Name, tc.Overlap, report.Overlap)
+		}
+		if report.Nondeterministic != tc.Nondeterministic {
+			t.Errorf("%s nondeterministic want:%t, got:%t",
+				tc.Name, tc.Nondeterministic, report.Nondeterministic)
+		}
+	}
+}
+
+func TestChunkRefsCycle(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	// content addressing prevents writing a real cycle, so cycleReader
+	// serves a parts blob which links to itself.
+	chunkRef, err := bs.Write(ctx, []byte("foo"))
+	if err != nil {
+		t.Fatalf("write chunk: %v", err)
+	}
+
+	loop := fixity.Ref("loop")
+	r := cycleReader{bs: bs, loop: loop, chunk: chunkRef}
+
+	_, err = ChunkRefs(ctx, r, "data")
+	if !errors.Is(err, fixity.ErrChainCycle) {
+		t.Errorf("chunkrefs want:%v, got:%v", fixity.ErrChainCycle, err)
+	}
+}
+
+// cycleReader serves a data blob whose MoreParts links to a parts blob
+// which links to itself.
+type cycleReader struct {
+	bs    fixity.BlobReader
+	loop  fixity.Ref
+	chunk fixity.Ref
+}
+
+func (r cycleReader) Read(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	parts := fixity.PartsSchema{
+		Schema:    fixity.Schema{SchemaType: fixity.BlobTypeParts},
+		Parts:     []fixity.Ref{r.chunk},
+		MoreParts: &r.loop,
+	}
+
+	var v interface{}
+	switch ref {
+	case "data":
+		parts.SchemaType = fixity.BlobTypeData
+		v = fixity.DataSchema{PartsSchema: parts}
+	case r.loop:
+		v = parts
+	default:
+		return r.bs.Read(ctx, ref)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
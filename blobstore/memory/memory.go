@@ -3,14 +3,13 @@ package memory
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"sync"
 
-	base58 "github.com/jbenet/go-base58"
 	"github.com/leeola/fixity"
-	blake2b "github.com/minio/blake2b-simd"
 )
 
 // Store is a memory store used for testing.
@@ -31,7 +30,7 @@ func (s *Store) Read(_ context.Context, ref fixity.Ref) (io.ReadCloser, error) {
 
 	b, ok := s.m[ref]
 	if !ok {
-		return nil, os.ErrNotExist
+		return nil, &fixity.RefError{Op: "read", Ref: ref, Err: os.ErrNotExist}
 	}
 
 	return ioutil.NopCloser(bytes.NewReader(b)), nil
@@ -41,8 +40,43 @@ func (s *Store) Write(_ context.Context, b []byte) (fixity.Ref, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	hB := blake2b.Sum256(b)
-	ref := fixity.Ref(base58.Encode(hB[:]))
-	s.m[ref] = b
+	ref, err := fixity.Hash(b)
+	if err != nil {
+		return "", fmt.Errorf("hash: %v", err)
+	}
+
+	// copy, as callers such as chunkers may reuse b.
+	s.m[ref] = append([]byte(nil), b...)
 	return ref, nil
 }
+
+func (s *Store) Exists(_ context.Context, ref fixity.Ref) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.m[ref]
+	return ok, nil
+}
+
+func (s *Store) Size(_ context.Context, ref fixity.Ref) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.m[ref]
+	if !ok {
+		return 0, &fixity.RefError{Op: "size", Ref: ref, Err: os.ErrNotExist}
+	}
+
+	return int64(len(b)), nil
+}
+
+func (s *Store) List(_ context.Context) ([]fixity.Ref, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refs := make([]fixity.Ref, 0, len(s.m))
+	for ref := range s.m {
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
@@ -0,0 +1,56 @@
+package chunk
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Constructor returns a new Chunker over the given reader.
+type Constructor func(io.Reader) (Chunker, error)
+
+var (
+	registry   = map[string]Constructor{}
+	registryMu sync.Mutex
+)
+
+// Register makes a chunker available by name, such as in store configs.
+//
+// Chunker packages are expected to call this in init, so that importing
+// the package is all that is needed to use the chunker.
+func Register(name string, c Constructor) {
+	if name == "" {
+		panic("chunker name cannot be empty")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("already registered chunker: %s", name))
+	}
+
+	registry[name] = c
+}
+
+// Registered reports whether a chunker of the given name is registered.
+func Registered(name string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	_, ok := registry[name]
+	return ok
+}
+
+// New returns a Chunker over the reader from the named constructor.
+func New(name string, r io.Reader) (Chunker, error) {
+	registryMu.Lock()
+	c, ok := registry[name]
+	registryMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("chunker not found: %q", name)
+	}
+
+	return c(r)
+}
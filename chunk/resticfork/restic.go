@@ -72,6 +72,15 @@ type Chunker struct {
 	chunker *chunker.Chunker
 }
 
+// Name is the registered chunk.Chunker name of this package.
+const Name = "resticfork"
+
+func init() {
+	chunk.Register(Name, func(r io.Reader) (chunk.Chunker, error) {
+		return New(r, DefaultAverageChunkSize)
+	})
+}
+
 func New(r io.Reader, averageChunkSize uint64) (*Chunker, error) {
 	if r == nil {
 		return nil, errors.New("missing Reader")
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/urfave/cli"
+)
+
+// benchReport summarizes the write and read performance of a blobstore.
+type benchReport struct {
+	Blobs int `json:"blobs"`
+	Size  int `json:"size"`
+
+	// WriteThroughput and ReadThroughput are in bytes per second.
+	WriteThroughput float64 `json:"writeThroughput"`
+	ReadThroughput  float64 `json:"readThroughput"`
+
+	WriteLatency latencies `json:"writeLatency"`
+	ReadLatency  latencies `json:"readLatency"`
+}
+
+type latencies struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+}
+
+func BenchStoreCmd(clictx *cli.Context) error {
+	blobs, size := clictx.Int("blobs"), clictx.Int("size")
+	if blobs <= 0 || size <= 0 {
+		return errors.New("blobs and size must be greater than zero")
+	}
+
+	c, err := config.Open(clictx.GlobalString("config"))
+	if err != nil {
+		return fmt.Errorf("open config: %v", err)
+	}
+
+	// blobstore paths are joined to the root path, so a temporary root
+	// keeps benchmark blobs out of the configured store, and allows
+	// them to be removed afterwards.
+	tmp, err := ioutil.TempDir("", "fixi-bench")
+	if err != nil {
+		return fmt.Errorf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	c.RootPath = tmp
+
+	name := clictx.String("blobstore")
+	bs, err := fixity.NewBlobstoreFromConfig(name, c)
+	if err != nil {
+		return fmt.Errorf("blobstore %q: %v", name, err)
+	}
+	if closer, ok := bs.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	report, err := benchBlobstore(context.Background(), bs, blobs, size)
+	if err != nil {
+		return err // no wrap helper err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "\tMB/S\tP50\tP90\tP99\t\n")
+	fmt.Fprintf(w, "write\t%.2f\t%s\t%s\t%s\t\n", report.WriteThroughput/1e6,
+		report.WriteLatency.P50, report.WriteLatency.P90, report.WriteLatency.P99)
+	fmt.Fprintf(w, "read\t%.2f\t%s\t%s\t%s\t\n", report.ReadThroughput/1e6,
+		report.ReadLatency.P50, report.ReadLatency.P90, report.ReadLatency.P99)
+	w.Flush()
+
+	return nil
+}
+
+// benchBlobstore writes n random blobs of size bytes, then reads them
+// back in a random order, reporting throughput and latency of both.
+func benchBlobstore(ctx context.Context, bs fixity.Blobstore, n, size int) (benchReport, error) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	blobs := make([][]byte, n)
+	for i := range blobs {
+		blobs[i] = make([]byte, size)
+		rnd.Read(blobs[i])
+	}
+
+	refs := make([]fixity.Ref, n)
+	writeTimes := make([]time.Duration, n)
+	writeStart := time.Now()
+	for i, b := range blobs {
+		start := time.Now()
+		ref, err := bs.Write(ctx, b)
+		if err != nil {
+			return benchReport{}, fmt.Errorf("write %d: %v", i, err)
+		}
+		writeTimes[i] = time.Since(start)
+		refs[i] = ref
+	}
+	writeElapsed := time.Since(writeStart)
+
+	readTimes := make([]time.Duration, n)
+	readStart := time.Now()
+	for i, j := range rnd.Perm(n) {
+		start := time.Now()
+		rc, err := bs.Read(ctx, refs[j])
+		if err != nil {
+			return benchReport{}, fmt.Errorf("read %s: %v", refs[j], err)
+		}
+		read, err := io.Copy(ioutil.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return benchReport{}, fmt.Errorf("read %s: %v", refs[j], err)
+		}
+		readTimes[i] = time.Since(start)
+
+		if read != int64(size) {
+			return benchReport{}, fmt.Errorf("read %s: want %d bytes, got %d", refs[j], size, read)
+		}
+	}
+	readElapsed := time.Since(readStart)
+
+	total := float64(n * size)
+	return benchReport{
+		Blobs:           n,
+		Size:            size,
+		WriteThroughput: total / nonZero(writeElapsed).Seconds(),
+		ReadThroughput:  total / nonZero(readElapsed).Seconds(),
+		WriteLatency:    percentiles(writeTimes),
+		ReadLatency:     percentiles(readTimes),
+	}, nil
+}
+
+// nonZero avoids dividing by zero durations on coarse clocks.
+func nonZero(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Nanosecond
+	}
+	return d
+}
+
+func percentiles(ds []time.Duration) latencies {
+	if len(ds) == 0 {
+		return latencies{}
+	}
+
+	sorted := append([]time.Duration{}, ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	at := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+
+	return latencies{P50: at(50), P90: at(90), P99: at(99)}
+}
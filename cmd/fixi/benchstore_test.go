@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity/blobstore/memory"
+)
+
+func TestBenchBlobstore(t *testing.T) {
+	report, err := benchBlobstore(context.Background(), memory.New(), 10, 64)
+	if err != nil {
+		t.Fatalf("benchblobstore: %v", err)
+	}
+
+	if report.WriteThroughput <= 0 || report.ReadThroughput <= 0 {
+		t.Errorf("throughput want nonzero, got write:%f read:%f",
+			report.WriteThroughput, report.ReadThroughput)
+	}
+
+	if report.ReadLatency.P50 > report.ReadLatency.P99 {
+		t.Errorf("read p50 %s greater than p99 %s", report.ReadLatency.P50, report.ReadLatency.P99)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	ds := make([]time.Duration, 100)
+	for i := range ds {
+		// reversed, to confirm sorting.
+		ds[i] = time.Duration(100-i) * time.Millisecond
+	}
+
+	l := percentiles(ds)
+	if l.P50 != 50*time.Millisecond || l.P90 != 90*time.Millisecond || l.P99 != 99*time.Millisecond {
+		t.Errorf("percentiles want 50ms 90ms 99ms, got:%s %s %s", l.P50, l.P90, l.P99)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/leeola/fixity"
+	"github.com/urfave/cli"
+)
+
+// maxBulkLineSize is the largest jsonl record bulk import accepts,
+// including base64 encoded data.
+const maxBulkLineSize = 64 * 1024 * 1024
+
+// bulkRecord is a single line of a bulk import file.
+type bulkRecord struct {
+	ID string `json:"id"`
+
+	// Fields are key=value pairs, as with the write --kv flag.
+	Fields []string `json:"fields,omitempty"`
+
+	// Data is the base64 encoded content, if any.
+	Data []byte `json:"data,omitempty"`
+}
+
+type writer interface {
+	Write(ctx context.Context, id string, v fixity.Values, r io.Reader) ([]fixity.Ref, error)
+}
+
+func BulkImportCmd(clictx *cli.Context) error {
+	if len(clictx.Args()) != 1 {
+		return errors.New("requires exactly one jsonl file")
+	}
+
+	f, err := os.Open(clictx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("open: %v", err)
+	}
+	defer f.Close()
+
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	imported, failed, err := bulkImport(context.Background(), s, f, os.Stdout)
+	if err != nil {
+		return err // no wrap helper err
+	}
+
+	if err := s.Sync(); err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	fmt.Printf("imported: %d, failed: %d\n", imported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d records failed to import", failed)
+	}
+
+	return nil
+}
+
+// bulkImport writes every jsonl record of r, reporting the result of
+// each line to out. Malformed or failed records are reported and
+// counted, but do not stop the import.
+func bulkImport(ctx context.Context, w writer, r io.Reader, out io.Writer) (imported, failed int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxBulkLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		ref, err := importRecord(ctx, w, b)
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "line %d: error: %v\n", line, err)
+			continue
+		}
+
+		imported++
+		fmt.Fprintf(out, "line %d: %s\n", line, ref)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return imported, failed, fmt.Errorf("scan: %v", err)
+	}
+
+	return imported, failed, nil
+}
+
+// importRecord writes a single jsonl record, returning the mutation ref.
+func importRecord(ctx context.Context, w writer, b []byte) (fixity.Ref, error) {
+	var rec bulkRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return "", fmt.Errorf("unmarshal: %v", err)
+	}
+
+	if rec.ID == "" {
+		return "", errors.New("missing id")
+	}
+
+	values, err := kvValues(rec.Fields, nil)
+	if err != nil {
+		return "", err // no wrap helper err
+	}
+
+	var data io.Reader
+	if len(rec.Data) > 0 {
+		data = bytes.NewReader(rec.Data)
+	}
+
+	refs, err := w.Write(ctx, rec.ID, values, data)
+	if err != nil {
+		return "", fmt.Errorf("write: %v", err)
+	}
+
+	// the mutation is always the last ref written.
+	return refs[len(refs)-1], nil
+}
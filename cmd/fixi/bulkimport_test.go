@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+)
+
+type recordingWriter struct {
+	values map[string]fixity.Values
+	data   map[string]string
+}
+
+func (w *recordingWriter) Write(_ context.Context, id string, v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
+	w.values[id] = v
+	if r != nil {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		w.data[id] = string(b)
+	}
+	return []fixity.Ref{fixity.Ref("ref-" + id)}, nil
+}
+
+func TestBulkImport(t *testing.T) {
+	input := strings.Join([]string{
+		`{"id":"a","fields":["color=blue","tag=x","tag=y"]}`,
+		`{"id":"b","data":"Zm9v"}`,
+		``,
+		`not json`,
+		`{"fields":["color=red"]}`,
+		`{"id":"c","fields":["invalid"]}`,
+	}, "\n")
+
+	w := &recordingWriter{values: map[string]fixity.Values{}, data: map[string]string{}}
+	var out bytes.Buffer
+
+	imported, failed, err := bulkImport(context.Background(), w, strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatalf("bulkimport: %v", err)
+	}
+
+	if imported != 2 {
+		t.Errorf("imported want:2, got:%d", imported)
+	}
+	if failed != 3 {
+		t.Errorf("failed want:3, got:%d", failed)
+	}
+
+	if got := w.values["a"]["color"].StringValue; got != "blue" {
+		t.Errorf("a color want:blue, got:%s", got)
+	}
+	if got := len(w.values["a"]["tag"].ListValue); got != 2 {
+		t.Errorf("a tag list want:2 values, got:%d", got)
+	}
+	if got := w.data["b"]; got != "foo" {
+		t.Errorf("b data want:foo, got:%q", got)
+	}
+
+	for _, expect := range []string{"line 1: ref-a", "line 2: ref-b", "line 4: error", "line 5: error", "line 6: error"} {
+		if !strings.Contains(out.String(), expect) {
+			t.Errorf("output missing %q:\n%s", expect, out.String())
+		}
+	}
+}
+
+func TestBulkImportSearchable(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-fixi")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the default config, of a disk blobstore and bleve index.
+	c, err := config.NewConfig()
+	if err != nil {
+		t.Fatalf("newconfig: %v", err)
+	}
+	c.RootPath = dir
+	c.Log = false
+
+	s, err := fixity.NewFromConfig("", c)
+	if err != nil {
+		t.Fatalf("newfromconfig: %v", err)
+	}
+
+	input := strings.Join([]string{
+		`{"id":"a","fields":["color=blue"]}`,
+		`{"id":"b","fields":["color=red"],"data":"Zm9v"}`,
+		`{"id":"c","fields":["color=blue"]}`,
+	}, "\n")
+
+	if _, failed, err := bulkImport(ctx, s, strings.NewReader(input), ioutil.Discard); err != nil || failed != 0 {
+		t.Fatalf("bulkimport failed:%d, err:%v", failed, err)
+	}
+
+	testCases := []struct {
+		Query  q.Query
+		Expect []string
+	}{
+		{q.New().Const(q.IdIn("a", "b", "c")).Limit(3), []string{"a", "b", "c"}},
+		{q.New().Eq("color", value.String("blue")), []string{"a", "c"}},
+		{q.New().Eq("color", value.String("red")), []string{"b"}},
+	}
+
+	for _, tc := range testCases {
+		matches, err := s.Query(tc.Query)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+
+		var ids []string
+		for _, m := range matches {
+			ids = append(ids, m.ID)
+		}
+		sort.Strings(ids)
+
+		if !reflect.DeepEqual(ids, tc.Expect) {
+			t.Errorf("query %v ids want:%v, got:%v", tc.Query.Constraint, tc.Expect, ids)
+		}
+	}
+
+	_, _, r, err := s.Read(ctx, "b")
+	if err != nil {
+		t.Fatalf("read b: %v", err)
+	}
+	defer r.Close()
+	if b, _ := ioutil.ReadAll(r); string(b) != "foo" {
+		t.Errorf("b data want:foo, got:%q", b)
+	}
+}
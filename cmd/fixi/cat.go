@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/fatih/color"
 	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/reader/codecreader"
 	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli"
 )
@@ -75,6 +77,10 @@ func readToWriters(clictx *cli.Context, wout, werr io.Writer, dataMsg string) er
 		}
 	}
 
+	if r != nil {
+		defer r.Close()
+	}
+
 	if !clictx.Bool("no-mutation") {
 		fmt.Fprintln(werr, "mutation:")
 		if err := printAsJSON(werr, mutation); err != nil {
@@ -89,10 +95,73 @@ func readToWriters(clictx *cli.Context, wout, werr io.Writer, dataMsg string) er
 		}
 	}
 
+	if r == nil {
+		return nil
+	}
+
+	var data io.Reader
+	if clictx.Bool("decompress") {
+		// offsets of compressed data would be meaningless to callers
+		// expecting decompressed bytes.
+		if clictx.Int64("offset") != 0 || clictx.Int64("length") != 0 {
+			return errors.New("offset and length cannot be used with decompress")
+		}
+
+		rc, err := codecreader.Decompress(values, r)
+		if err != nil {
+			return fmt.Errorf("decompress: %v", err)
+		}
+		defer rc.Close()
+		data = rc
+	} else {
+		data, err = sliceReader(r, clictx.Int64("offset"), clictx.Int64("length"))
+		if err != nil {
+			return err // no wrap helper err
+		}
+	}
+
 	fmt.Fprintln(werr, dataMsg)
-	if _, err := io.Copy(wout, r); err != nil {
+	if _, err := io.Copy(wout, data); err != nil {
 		return fmt.Errorf("copy wout: %v", err)
 	}
 
 	return nil
 }
+
+// sliceReader returns a reader of length bytes of r starting at offset,
+// such as to preview the start of large content. A length of zero reads
+// to the end.
+//
+// Readers which are io.Seekers, such as data readers, skip to offset
+// rather than reading the preceding bytes.
+func sliceReader(r fixity.Reader, offset, length int64) (io.Reader, error) {
+	if offset < 0 || length < 0 {
+		return nil, errors.New("offset and length cannot be negative")
+	}
+
+	if offset == 0 && length == 0 {
+		return r, nil
+	}
+
+	size, err := r.Size()
+	if err != nil {
+		return nil, fmt.Errorf("size: %v", err)
+	}
+
+	if offset > size {
+		return nil, fmt.Errorf("offset %d beyond content size %d", offset, size)
+	}
+	if length == 0 || offset+length > size {
+		length = size - offset
+	}
+
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("seek: %v", err)
+		}
+	} else if _, err := io.CopyN(ioutil.Discard, r, offset); err != nil {
+		return nil, fmt.Errorf("discard: %v", err)
+	}
+
+	return io.LimitReader(r, length), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore/memory"
+	"github.com/leeola/fixity/chunk/resticfork"
+	"github.com/leeola/fixity/reader/datareader"
+	"github.com/leeola/fixity/util/wutil"
+)
+
+// bytesReader is a fixity.Reader which is not an io.Seeker.
+type bytesReader struct {
+	*bytes.Reader
+}
+
+func (r bytesReader) Size() (int64, error)      { return r.Reader.Size(), nil }
+func (r bytesReader) Checksum() (string, error) { return "", nil }
+func (r bytesReader) Close() error              { return nil }
+
+func TestSliceReader(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	chunker, err := resticfork.New(bytes.NewReader(content), 64)
+	if err != nil {
+		t.Fatalf("resticfork: %v", err)
+	}
+	chunkRefs, size, checksum, err := wutil.WriteChunks(ctx, bs, chunker, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+	refs, _, err := wutil.WriteData(ctx, bs, chunkRefs, size, checksum, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+	dataRef := refs[len(refs)-1]
+
+	testCases := []struct {
+		Offset, Length int64
+		Expect         []byte
+		Err            bool
+	}{
+		{Offset: 0, Length: 100, Expect: content[:100]},
+		{Offset: 0, Length: 0, Expect: content},
+		{Offset: 500, Length: 10, Expect: content[500:510]},
+		{Offset: 990, Length: 100, Expect: content[990:]},
+		{Offset: 1000, Length: 0, Expect: []byte{}},
+		{Offset: 1001, Err: true},
+		{Offset: -1, Err: true},
+	}
+
+	for _, tc := range testCases {
+		dr, err := datareader.New(ctx, bs, dataRef)
+		if err != nil {
+			t.Fatalf("datareader: %v", err)
+		}
+
+		readers := map[string]fixity.Reader{
+			"seeker":    dr,
+			"nonseeker": bytesReader{bytes.NewReader(content)},
+		}
+		for name, r := range readers {
+			sr, err := sliceReader(r, tc.Offset, tc.Length)
+			if tc.Err {
+				if err == nil {
+					t.Errorf("%s offset:%d length:%d want error", name, tc.Offset, tc.Length)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s offset:%d length:%d: %v", name, tc.Offset, tc.Length, err)
+			}
+
+			b, err := ioutil.ReadAll(sr)
+			if err != nil {
+				t.Fatalf("%s readall: %v", name, err)
+			}
+			if !bytes.Equal(b, tc.Expect) {
+				t.Errorf("%s offset:%d length:%d unexpected content",
+					name, tc.Offset, tc.Length)
+			}
+		}
+		dr.Close()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/leeola/fixity/config"
+	"github.com/urfave/cli"
+)
+
+func ConfigShowCmd(clictx *cli.Context) error {
+	c, err := config.Open(clictx.GlobalString("config"))
+	if err == config.ErrNotExist {
+		// show the default config that would be generated.
+		c, err = config.NewConfig()
+		if err != nil {
+			return fmt.Errorf("new config: %v", err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("open config: %v", err)
+	}
+
+	if !clictx.Bool("show-secrets") {
+		c, err = c.Redacted()
+		if err != nil {
+			return fmt.Errorf("redact config: %v", err)
+		}
+	}
+
+	if err := printAsJSON(os.Stdout, c); err != nil {
+		return fmt.Errorf("print config: %v", err)
+	}
+
+	return nil
+}
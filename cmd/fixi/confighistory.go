@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/index"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+	"github.com/urfave/cli"
+)
+
+// configID is the reserved id that configs are saved under, versioning
+// the config alongside the content it configures.
+const configID = "__config__"
+
+type configStore interface {
+	writer
+	refReader
+}
+
+// configVersion is a saved config, as listed by config history.
+type configVersion struct {
+	Ref    fixity.Ref    `json:"ref"`
+	Time   time.Time     `json:"time"`
+	Config config.Config `json:"config"`
+}
+
+func ConfigSaveCmd(clictx *cli.Context) error {
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	ref, err := saveConfigFile(context.Background(), s, clictx.GlobalString("config"))
+	if err != nil {
+		return err // no wrap helper err
+	}
+
+	if err := s.Sync(); err != nil {
+		return fmt.Errorf("sync: %v", err)
+	}
+
+	fmt.Println(ref)
+	return nil
+}
+
+func ConfigHistoryCmd(clictx *cli.Context) error {
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	versions, err := configHistory(context.Background(), s, clictx.Int("limit"))
+	if err != nil {
+		return err // no wrap helper err
+	}
+
+	if err := printAsJSON(os.Stdout, versions); err != nil {
+		return fmt.Errorf("print history: %v", err)
+	}
+
+	return nil
+}
+
+// saveConfigFile saves the config file at path as written, without
+// interpolating ${VAR} references, so that values from the environment
+// are never persisted to the store.
+func saveConfigFile(ctx context.Context, w writer, path string) (fixity.Ref, error) {
+	c, err := config.OpenRaw(path)
+	if err != nil {
+		return "", fmt.Errorf("open config: %v", err)
+	}
+
+	return saveConfig(ctx, w, c)
+}
+
+// saveConfig writes the redacted config as the data of configID,
+// returning the ref of the mutation.
+//
+// Secrets are redacted so that they are never persisted to the store,
+// where they could not be removed.
+func saveConfig(ctx context.Context, w writer, c config.Config) (fixity.Ref, error) {
+	c, err := c.Redacted()
+	if err != nil {
+		return "", fmt.Errorf("redact config: %v", err)
+	}
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal config: %v", err)
+	}
+
+	refs, err := w.Write(ctx, configID, nil, bytes.NewReader(b))
+	if err != nil {
+		return "", fmt.Errorf("write config: %v", err)
+	}
+
+	return refs[len(refs)-1], nil
+}
+
+// historyPageSize is the number of config versions queried at once.
+const historyPageSize = 100
+
+// configHistory returns up to limit saved configs, latest first.
+//
+// Index results are not ordered by time, so every saved config is read
+// and sorted before limiting. Saved configs are expected to be few and
+// small.
+func configHistory(ctx context.Context, s configStore, limit int) ([]configVersion, error) {
+	var versions []configVersion
+	for skip := 0; ; skip += historyPageSize {
+		qu := q.New().WithVersions().
+			Eq(index.FIDKey, value.String(configID)).
+			Limit(historyPageSize).
+			Skip(skip)
+
+		matches, err := s.Query(qu)
+		if err != nil {
+			return nil, fmt.Errorf("query: %v", err)
+		}
+
+		for _, m := range matches {
+			v, err := readConfigVersion(ctx, s, m.Ref)
+			if err != nil {
+				return nil, fmt.Errorf("read %q: %v", m.Ref, err)
+			}
+			versions = append(versions, v)
+		}
+
+		if len(matches) < historyPageSize {
+			break
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Time.After(versions[j].Time)
+	})
+
+	if limit > 0 && len(versions) > limit {
+		versions = versions[:limit]
+	}
+
+	return versions, nil
+}
+
+func readConfigVersion(ctx context.Context, s configStore, ref fixity.Ref) (configVersion, error) {
+	m, _, r, err := s.ReadRef(ctx, ref)
+	if err != nil {
+		return configVersion{}, fmt.Errorf("readref: %v", err)
+	}
+	if r == nil {
+		return configVersion{}, errors.New("config mutation missing data")
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return configVersion{}, fmt.Errorf("readall: %v", err)
+	}
+
+	var c config.Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return configVersion{}, fmt.Errorf("unmarshal: %v", err)
+	}
+
+	return configVersion{
+		Ref:    ref,
+		Time:   m.Time,
+		Config: c,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/index"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/q/operator"
+)
+
+// versionStore is an in memory configStore, returning every version of
+// the id for id queries, and every version of every id otherwise.
+//
+// Matches are returned in write order, paged by the query limit and
+// skip, so callers relying on the index order for time order are
+// caught.
+type versionStore struct {
+	mutations map[fixity.Ref]fixity.Mutation
+	values    map[fixity.Ref]fixity.Values
+	data      map[fixity.Ref][]byte
+	matches   []fixity.Match
+}
+
+func newVersionStore() *versionStore {
+	return &versionStore{
+		mutations: map[fixity.Ref]fixity.Mutation{},
+		values:    map[fixity.Ref]fixity.Values{},
+		data:      map[fixity.Ref][]byte{},
+	}
+}
+
+func (s *versionStore) Write(_ context.Context, id string, v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(s.matches)
+	ref := fixity.Ref(fmt.Sprintf("ref-%d", n))
+	s.mutations[ref] = fixity.Mutation{
+		ID:   id,
+		Time: time.Unix(int64(n), 0),
+	}
+	s.values[ref] = v
+	s.data[ref] = b
+	s.matches = append(s.matches, fixity.Match{ID: id, Ref: ref})
+	return []fixity.Ref{ref}, nil
+}
+
+func (s *versionStore) Query(qu q.Query) ([]fixity.Match, error) {
+	matches := s.matches
+
+	c := qu.Constraint
+	if c.Operator == operator.Equal && c.Field != nil && *c.Field == index.FIDKey {
+		matches = nil
+		for _, m := range s.matches {
+			if m.ID == c.Value.StringValue {
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	if qu.SkipBy >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[qu.SkipBy:]
+	if qu.LimitBy > 0 && len(matches) > qu.LimitBy {
+		matches = matches[:qu.LimitBy]
+	}
+	return matches, nil
+}
+
+func (s *versionStore) ReadRef(_ context.Context, ref fixity.Ref) (
+	fixity.Mutation, fixity.Values, fixity.Reader, error) {
+
+	m, ok := s.mutations[ref]
+	if !ok {
+		return fixity.Mutation{}, nil, nil, errors.New("not found")
+	}
+	return m, s.values[ref], bytesReader{bytes.NewReader(s.data[ref])}, nil
+}
+
+func TestConfigHistory(t *testing.T) {
+	ctx := context.Background()
+	s := newVersionStore()
+
+	for _, rootPath := range []string{"~/.fixity", "/var/lib/fixity"} {
+		if _, err := saveConfig(ctx, s, config.Config{RootPath: rootPath}); err != nil {
+			t.Fatalf("save %s: %v", rootPath, err)
+		}
+	}
+
+	if s.matches[0].ID != configID {
+		t.Errorf("saved id want:%s, got:%s", configID, s.matches[0].ID)
+	}
+
+	versions, err := configHistory(ctx, s, 10)
+	if err != nil {
+		t.Fatalf("confighistory: %v", err)
+	}
+
+	if len(versions) != 2 {
+		t.Fatalf("versions want:2, got:%d", len(versions))
+	}
+
+	expect := []string{"/var/lib/fixity", "~/.fixity"}
+	for i, v := range versions {
+		if v.Config.RootPath != expect[i] {
+			t.Errorf("version %d rootPath want:%s, got:%s", i, expect[i], v.Config.RootPath)
+		}
+	}
+}
+
+func TestSaveConfigFileEnv(t *testing.T) {
+	ctx := context.Background()
+	s := newVersionStore()
+
+	os.Setenv("FIXITY_TEST_SECRET", "hunter2")
+	defer os.Unsetenv("FIXITY_TEST_SECRET")
+
+	dir, err := ioutil.TempDir("", "fixity-config")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	raw := `{"rootPath": "/var/lib/${FIXITY_TEST_SECRET}"}`
+	if err := ioutil.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatalf("writefile: %v", err)
+	}
+
+	if _, err := saveConfigFile(ctx, s, path); err != nil {
+		t.Fatalf("saveconfigfile: %v", err)
+	}
+
+	for ref, b := range s.data {
+		if bytes.Contains(b, []byte("hunter2")) {
+			t.Errorf("saved config %q contains env value: %s", ref, b)
+		}
+	}
+
+	versions, err := configHistory(ctx, s, 0)
+	if err != nil {
+		t.Fatalf("confighistory: %v", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("versions want:1, got:%d", len(versions))
+	}
+	if want := "/var/lib/${FIXITY_TEST_SECRET}"; versions[0].Config.RootPath != want {
+		t.Errorf("rootPath want:%s, got:%s", want, versions[0].Config.RootPath)
+	}
+}
+
+func TestConfigHistoryLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newVersionStore()
+
+	// more than a page, confirming the latest are found on later pages.
+	saves := historyPageSize + 5
+	for i := 0; i < saves; i++ {
+		c := config.Config{RootPath: fmt.Sprintf("root-%d", i)}
+		if _, err := saveConfig(ctx, s, c); err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+	}
+
+	versions, err := configHistory(ctx, s, 3)
+	if err != nil {
+		t.Fatalf("confighistory: %v", err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("versions want:3, got:%d", len(versions))
+	}
+	for i, v := range versions {
+		want := fmt.Sprintf("root-%d", saves-1-i)
+		if v.Config.RootPath != want {
+			t.Errorf("version %d rootPath want:%s, got:%s", i, want, v.Config.RootPath)
+		}
+	}
+}
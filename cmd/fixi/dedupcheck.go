@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore"
+	"github.com/urfave/cli"
+)
+
+func DedupCheckCmd(clictx *cli.Context) error {
+	if len(clictx.Args()) != 2 {
+		return errors.New("requires exactly two ids")
+	}
+
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	ctx := context.Background()
+
+	var dataRefs []fixity.Ref
+	for _, id := range clictx.Args() {
+		mutation, _, r, err := s.Read(ctx, id)
+		if err != nil {
+			return fmt.Errorf("read %q: %v", id, err)
+		}
+		if r != nil {
+			r.Close()
+		}
+
+		if mutation.DataSchema == "" {
+			return fmt.Errorf("id %q has no data", id)
+		}
+		dataRefs = append(dataRefs, mutation.DataSchema)
+	}
+
+	report, err := blobstore.CompareChunks(ctx, storeBlobReader{s}, dataRefs[0], dataRefs[1])
+	if err != nil {
+		return fmt.Errorf("comparechunks: %v", err)
+	}
+
+	return printAsJSON(os.Stdout, report)
+}
+
+// storeBlobReader adapts a Store to a BlobReader.
+type storeBlobReader struct {
+	s fixity.Store
+}
+
+func (r storeBlobReader) Read(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	return r.s.Blob(ctx, ref)
+}
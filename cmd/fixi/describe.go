@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/leeola/fixity"
+	"github.com/urfave/cli"
+)
+
+func DescribeCmd(clictx *cli.Context) error {
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	for _, sRef := range clictx.Args() {
+		ref, err := fixity.ParseRef(sRef)
+		if err != nil {
+			return err
+		}
+
+		desc, err := s.Describe(context.Background(), ref)
+		if err != nil {
+			return fmt.Errorf("describe %q: %v", ref, err)
+		}
+
+		if err := printAsJSON(os.Stdout, desc); err != nil {
+			return fmt.Errorf("print description: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+	"github.com/urfave/cli"
+)
+
+// checkStatus is the health of a doctor check, ordered by severity.
+type checkStatus int
+
+const (
+	statusGreen checkStatus = iota
+	statusYellow
+	statusRed
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case statusGreen:
+		return "green"
+	case statusYellow:
+		return "yellow"
+	default:
+		return "red"
+	}
+}
+
+type checkResult struct {
+	Name   string
+	Status checkStatus
+	Detail string
+}
+
+type doctorStore interface {
+	refReader
+	Blob(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error)
+}
+
+func DoctorCmd(clictx *cli.Context) error {
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		fmt.Printf("store\t%s\t%v\n", statusRed, err)
+		return errors.New("store could not be opened")
+	}
+
+	results := doctor(context.Background(), s)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "CHECK\tSTATUS\tDETAIL\t\n")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", r.Name, r.Status, r.Detail)
+	}
+	w.Flush()
+
+	overall := worstStatus(results)
+	fmt.Println("overall:", overall)
+
+	if overall == statusRed {
+		return errors.New("unhealthy store")
+	}
+
+	return nil
+}
+
+// doctor runs quick health checks of the store, its blobstore and its
+// index. It is not a full consistency check, only reading enough to
+// catch obvious breakage.
+func doctor(ctx context.Context, s doctorStore) []checkResult {
+	var results []checkResult
+
+	// a ref which will never exist, confirming the blobstore responds
+	// with not found rather than an error.
+	probe, err := fixity.Hash([]byte("fixi doctor probe"))
+	if err != nil {
+		return append(results, checkResult{"blobstore", statusRed, fmt.Sprintf("hash: %v", err)})
+	}
+
+	blob := checkResult{Name: "blobstore", Status: statusGreen, Detail: "reachable"}
+	if rc, err := s.Blob(ctx, probe); err == nil {
+		rc.Close()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		blob.Status, blob.Detail = statusRed, err.Error()
+	}
+	results = append(results, blob)
+
+	matches, err := s.Query(q.New().Const(q.IdPrefix("")).Limit(1))
+	if err != nil {
+		return append(results, checkResult{"index", statusRed, err.Error()})
+	}
+	if len(matches) == 0 {
+		return append(results, checkResult{"index", statusYellow, "responsive, but empty"})
+	}
+	results = append(results, checkResult{"index", statusGreen, "responsive"})
+
+	// the index should only reference mutations in the blobstore.
+	m := matches[0]
+	latest := checkResult{Name: "indexed mutation", Status: statusGreen, Detail: fmt.Sprintf("%s readable", m.ID)}
+	if _, _, r, err := s.ReadRef(ctx, m.Ref); err != nil {
+		latest.Status = statusRed
+		latest.Detail = fmt.Sprintf("%s references unreadable mutation %s: %v", m.ID, m.Ref, err)
+	} else if r != nil {
+		r.Close()
+	}
+	results = append(results, latest)
+
+	return results
+}
+
+func worstStatus(results []checkResult) checkStatus {
+	worst := statusGreen
+	for _, r := range results {
+		if r.Status > worst {
+			worst = r.Status
+		}
+	}
+	return worst
+}
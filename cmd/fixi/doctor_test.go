@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/leeola/fixity"
+)
+
+// blobVersionStore is a versionStore with an empty blobstore.
+type blobVersionStore struct {
+	*versionStore
+}
+
+func (s blobVersionStore) Blob(_ context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	return nil, &fixity.RefError{Op: "read", Ref: ref, Err: os.ErrNotExist}
+}
+
+func TestDoctor(t *testing.T) {
+	ctx := context.Background()
+
+	healthy := newVersionStore()
+	if _, err := healthy.Write(ctx, "foo", nil, strings.NewReader("foo")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// the index references a mutation missing from the blobstore.
+	dangling := newVersionStore()
+	if _, err := dangling.Write(ctx, "foo", nil, strings.NewReader("foo")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for ref := range dangling.mutations {
+		delete(dangling.mutations, ref)
+	}
+
+	testCases := []struct {
+		Name   string
+		Store  *versionStore
+		Expect checkStatus
+	}{
+		{Name: "healthy", Store: healthy, Expect: statusGreen},
+		{Name: "empty", Store: newVersionStore(), Expect: statusYellow},
+		{Name: "dangling", Store: dangling, Expect: statusRed},
+	}
+
+	for _, tc := range testCases {
+		results := doctor(ctx, blobVersionStore{tc.Store})
+		if got := worstStatus(results); got != tc.Expect {
+			t.Errorf("%s want:%s, got:%s %+v", tc.Name, tc.Expect, got, results)
+		}
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/leeola/fixity/config"
 	_ "github.com/leeola/fixity/defaultpkg"
 
+	// import optional backends
+	_ "github.com/leeola/fixity/blobstore/bolt"
+
 	"github.com/leeola/fixity"
 	"github.com/urfave/cli"
 )
@@ -26,6 +29,28 @@ func main() {
 	}
 
 	app.Commands = []cli.Command{
+		{
+			Name:   "bench-store",
+			Usage:  "benchmark writes and reads of a blobstore, in a temporary root path",
+			Action: BenchStoreCmd,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "blobstore",
+					Value: "default",
+					Usage: "benchmark the blobstore `NAME` of the config",
+				},
+				cli.IntFlag{
+					Name:  "blobs",
+					Value: 1000,
+					Usage: "write and read `N` blobs",
+				},
+				cli.IntFlag{
+					Name:  "size",
+					Value: 64 * 1024,
+					Usage: "write blobs of `BYTES` each",
+				},
+			},
+		},
 		{
 			Name:      "blob",
 			ArgsUsage: "HASH",
@@ -65,6 +90,108 @@ func main() {
 					Name:  "ref",
 					Usage: "read from mutation refs, not ids",
 				},
+				cli.Int64Flag{
+					Name:  "offset",
+					Usage: "read data starting at byte `N`",
+				},
+				cli.Int64Flag{
+					Name:  "length",
+					Usage: "read at most `N` bytes of data, 0 for all",
+				},
+				cli.BoolFlag{
+					Name:  "decompress",
+					Usage: "decompress data written with a codec value, such as codec=gzip",
+				},
+			},
+		},
+		{
+			Name:      "bulk-import",
+			ArgsUsage: "FILE",
+			Usage:     "write every json line of FILE, as {\"id\", \"fields\", \"data\"}",
+			Action:    BulkImportCmd,
+		},
+		{
+			Name:  "config",
+			Usage: "inspect the fixity config",
+			Subcommands: []cli.Command{
+				{
+					Name:   "show",
+					Usage:  "print the resolved config, with secrets redacted",
+					Action: ConfigShowCmd,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "show-secrets",
+							Usage: "do not redact secret values",
+						},
+					},
+				},
+				{
+					Name:   "save",
+					Usage:  "write the redacted config file, without env values, to the store",
+					Action: ConfigSaveCmd,
+				},
+				{
+					Name:   "history",
+					Usage:  "print previously saved configs, latest first",
+					Action: ConfigHistoryCmd,
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "limit",
+							Value: 10,
+							Usage: "print at most `N` configs",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:      "dedup-check",
+			ArgsUsage: "ID ID",
+			Usage:     "report the chunks shared between the data of two ids",
+			Action:    DedupCheckCmd,
+		},
+		{
+			Name:      "describe",
+			ArgsUsage: "HASH",
+			Usage:     "describe the type and references of a blob from HASH",
+			Action:    DescribeCmd,
+		},
+		{
+			Name:   "doctor",
+			Usage:  "run quick health checks of the store, blobstore and index",
+			Action: DoctorCmd,
+		},
+		{
+			Name:      "get",
+			ArgsUsage: "ID",
+			Usage:     "write the data of ID to a file, with a json sidecar of its metadata",
+			Action:    GetCmd,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "write data to `PATH`, and metadata to PATH.json",
+				},
+				cli.BoolFlag{
+					Name:  "no-meta",
+					Usage: "do not write the metadata sidecar",
+				},
+			},
+		},
+		{
+			Name:      "ls",
+			ArgsUsage: "PREFIX",
+			Usage:     "list ids beginning with PREFIX",
+			Action:    LsCmd,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "limit",
+					Value: 100,
+					Usage: "list at most `N` ids",
+				},
+				cli.IntFlag{
+					Name:  "skip",
+					Usage: "skip the first `N` ids, to page with limit",
+				},
 			},
 		},
 		{
@@ -73,7 +200,20 @@ func main() {
 			ArgsUsage: "QUERY",
 			Usage:     "search the store for QUERY",
 			Action:    QueryCmd,
-			Flags:     []cli.Flag{},
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "explain",
+					Usage: "print the translated index query and match scores",
+				},
+				cli.BoolFlag{
+					Name:  "fields",
+					Usage: "print the indexed fields of each match",
+				},
+				cli.StringSliceFlag{
+					Name:  "id",
+					Usage: "match only the id `ID`, repeat for any of multiple ids",
+				},
+			},
 		},
 		{
 			Name:      "read",
@@ -102,8 +242,50 @@ func main() {
 					Name:  "ref",
 					Usage: "read from mutation refs, not ids",
 				},
+				cli.Int64Flag{
+					Name:  "offset",
+					Usage: "read data starting at byte `N`",
+				},
+				cli.Int64Flag{
+					Name:  "length",
+					Usage: "read at most `N` bytes of data, 0 for all",
+				},
+				cli.BoolFlag{
+					Name:  "decompress",
+					Usage: "decompress data written with a codec value, such as codec=gzip",
+				},
+			},
+		},
+		{
+			Name:   "store-diff",
+			Usage:  "report blobs missing between two stores",
+			Action: StoreDiffCmd,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "a",
+					Usage: "load store a from config `PATH`",
+				},
+				cli.StringFlag{
+					Name:  "b",
+					Usage: "load store b from config `PATH`",
+				},
+				cli.StringFlag{
+					Name:  "blobstore",
+					Value: "default",
+					Usage: "compare the blobstore `NAME` of each config",
+				},
+				cli.BoolFlag{
+					Name:  "print-missing",
+					Usage: "print the hash of each missing blob",
+				},
 			},
 		},
+		{
+			Name:      "validate",
+			ArgsUsage: "HASH",
+			Usage:     "check that blobs have the fields required by their schema type",
+			Action:    ValidateCmd,
+		},
 		{
 			Name:      "write",
 			Aliases:   []string{"w"},
@@ -116,12 +298,16 @@ func main() {
 				},
 				cli.StringSliceFlag{
 					Name:  "kv",
-					Usage: "a key=value pair to index write",
+					Usage: "a key=value pair to index write, repeat a key for multiple values",
 				},
 				cli.BoolFlag{
 					Name:  "stdin",
 					Usage: "upload from stdin",
 				},
+				cli.BoolFlag{
+					Name:  "file-values",
+					Usage: "index the filename, size, mime and modtime of files, and the duration, resolution and codecs of mp4 videos",
+				},
 				cli.BoolFlag{
 					Name:  "preview",
 					Usage: "preview blobs with schemas",
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+	"github.com/urfave/cli"
+)
+
+// refReader finds and reads mutations by ref.
+type refReader interface {
+	fixity.Querier
+	ReadRef(context.Context, fixity.Ref) (fixity.Mutation, fixity.Values, fixity.Reader, error)
+}
+
+// getMeta is the sidecar metadata written alongside a content by get.
+type getMeta struct {
+	ID        string     `json:"id"`
+	Namespace string     `json:"namespace,omitempty"`
+	Ref       fixity.Ref `json:"ref"`
+	Time      time.Time  `json:"time"`
+
+	DataSchema        fixity.Ref `json:"dataSchema"`
+	Size              int64      `json:"size"`
+	Checksum          string     `json:"checksum"`
+	ChecksumAlgorithm string     `json:"checksumAlgorithm,omitempty"`
+
+	Values fixity.Values `json:"values,omitempty"`
+}
+
+func GetCmd(clictx *cli.Context) error {
+	if len(clictx.Args()) != 1 {
+		return errors.New("requires exactly one id")
+	}
+
+	out := clictx.String("output")
+	if out == "" {
+		return errors.New("missing --output path")
+	}
+
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	id := clictx.Args().Get(0)
+	if err := getToFile(context.Background(), s, id, out, !clictx.Bool("no-meta")); err != nil {
+		return err // no wrap helper err
+	}
+
+	return nil
+}
+
+// getToFile writes the data of the latest mutation of id to the out
+// path, and if meta is true, a json sidecar of the mutation and its
+// values to out + ".json".
+func getToFile(ctx context.Context, s refReader, id, out string, meta bool) error {
+	matches, err := s.Query(q.New().Const(q.Id(id)))
+	if err != nil {
+		return fmt.Errorf("query id: %v", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("id not found: %q", id)
+	}
+	if len(matches) > 1 {
+		return fmt.Errorf("id matched more than once: %q", id)
+	}
+	ref := matches[0].Ref
+
+	m, values, r, err := s.ReadRef(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("read %q: %v", ref, err)
+	}
+	if r == nil {
+		return fmt.Errorf("id has no data: %q", id)
+	}
+	defer r.Close()
+
+	if err := createFile(out, r); err != nil {
+		return err // no wrap helper err
+	}
+
+	if !meta {
+		return nil
+	}
+
+	size, err := r.Size()
+	if err != nil {
+		return fmt.Errorf("size: %v", err)
+	}
+	checksum, err := r.Checksum()
+	if err != nil {
+		return fmt.Errorf("checksum: %v", err)
+	}
+
+	// only data readers record the algorithm, which is otherwise the
+	// default.
+	var checksumAlgo string
+	if ar, ok := r.(interface{ ChecksumAlgorithm() (string, error) }); ok {
+		checksumAlgo, err = ar.ChecksumAlgorithm()
+		if err != nil {
+			return fmt.Errorf("checksum algorithm: %v", err)
+		}
+	}
+
+	b, err := json.MarshalIndent(getMeta{
+		ID:                m.ID,
+		Namespace:         m.Namespace,
+		Ref:               ref,
+		Time:              m.Time,
+		DataSchema:        m.DataSchema,
+		Size:              size,
+		Checksum:          checksum,
+		ChecksumAlgorithm: checksumAlgo,
+		Values:            values,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal meta: %v", err)
+	}
+
+	if err := ioutil.WriteFile(out+".json", b, 0644); err != nil {
+		return fmt.Errorf("write meta: %v", err)
+	}
+
+	return nil
+}
+
+// createFile creates, or truncates, the file at path with the contents
+// of r.
+func createFile(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("copy: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/value"
+)
+
+func TestGetToFile(t *testing.T) {
+	ctx := context.Background()
+
+	tmp, err := ioutil.TempDir("", "fixi-get")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s := newVersionStore()
+	v := fixity.Values{"color": value.String("blue")}
+	refs, err := s.Write(ctx, "foo", v, strings.NewReader("foo data"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	testCases := []struct {
+		Name string
+		Meta bool
+	}{
+		{Name: "with-meta.bin", Meta: true},
+		{Name: "no-meta.bin", Meta: false},
+	}
+
+	for _, tc := range testCases {
+		out := filepath.Join(tmp, tc.Name)
+		if err := getToFile(ctx, s, "foo", out, tc.Meta); err != nil {
+			t.Fatalf("%s gettofile: %v", tc.Name, err)
+		}
+
+		b, err := ioutil.ReadFile(out)
+		if err != nil {
+			t.Fatalf("%s readfile: %v", tc.Name, err)
+		}
+		if string(b) != "foo data" {
+			t.Errorf("%s data want:%q, got:%q", tc.Name, "foo data", b)
+		}
+
+		metaB, err := ioutil.ReadFile(out + ".json")
+		if !tc.Meta {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s sidecar want none, got:%v", tc.Name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s read sidecar: %v", tc.Name, err)
+		}
+
+		var meta getMeta
+		if err := json.Unmarshal(metaB, &meta); err != nil {
+			t.Fatalf("%s unmarshal sidecar: %v", tc.Name, err)
+		}
+		if meta.ID != "foo" || meta.Ref != refs[0] {
+			t.Errorf("%s sidecar want foo:%s, got:%s:%s", tc.Name, refs[0], meta.ID, meta.Ref)
+		}
+		if meta.Size != int64(len("foo data")) {
+			t.Errorf("%s sidecar size want:%d, got:%d", tc.Name, len("foo data"), meta.Size)
+		}
+		if got := meta.Values["color"].StringValue; got != "blue" {
+			t.Errorf("%s sidecar color want:blue, got:%s", tc.Name, got)
+		}
+	}
+
+	if err := getToFile(ctx, s, "missing", filepath.Join(tmp, "missing"), true); err == nil {
+		t.Errorf("missing id want error")
+	}
+}
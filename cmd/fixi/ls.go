@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/leeola/fixity/q"
+	"github.com/urfave/cli"
+)
+
+func LsCmd(clictx *cli.Context) error {
+	if len(clictx.Args()) > 1 {
+		return errors.New("too many args")
+	}
+
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	prefix := clictx.Args().Get(0)
+
+	qu := q.New().Const(q.IdPrefix(prefix)).
+		Skip(clictx.Int("skip")).
+		Limit(clictx.Int("limit"))
+	matches, err := s.Query(qu)
+	if err != nil {
+		return fmt.Errorf("query: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "ID\tREF\t\n")
+	for _, m := range matches {
+		fmt.Fprintf(w, "%s\t%s\t\n", m.ID, m.Ref)
+	}
+	w.Flush()
+
+	return nil
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/leeola/fixity/index"
 	"github.com/leeola/fixity/q"
 	"github.com/urfave/cli"
 )
@@ -18,12 +20,44 @@ func QueryCmd(clictx *cli.Context) error {
 	}
 
 	qStr := strings.Join(clictx.Args(), " ")
+	qu := q.FromString(qStr)
+	if ids := clictx.StringSlice("id"); len(ids) != 0 {
+		if qStr == "" {
+			qu = qu.Const(q.IdIn(ids...))
+		} else {
+			qu = qu.Const(qu.Constraint.And(q.IdIn(ids...)))
+		}
+		if qu.LimitBy < len(ids) {
+			qu = qu.Limit(len(ids))
+		}
+	}
+	if clictx.Bool("fields") {
+		qu = qu.WithFields()
+	}
+
+	if clictx.Bool("explain") {
+		e, ok := s.(index.Explainer)
+		if !ok {
+			return errors.New("store does not support explain")
+		}
+
+		explanation, err := e.Explain(qu)
+		if err != nil {
+			return fmt.Errorf("explain: %v", err)
+		}
 
-	matches, err := s.Query(q.FromString(qStr))
+		return printAsJSON(os.Stdout, explanation)
+	}
+
+	matches, err := s.Query(qu)
 	if err != nil {
 		return fmt.Errorf("query: %v", err)
 	}
 
+	if qu.IncludeFields {
+		return printAsJSON(os.Stdout, matches)
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 	fmt.Fprintf(w, "\tREF\tID\t\n")
 	for i, m := range matches {
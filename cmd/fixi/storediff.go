@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore"
+	"github.com/leeola/fixity/config"
+	"github.com/urfave/cli"
+)
+
+func StoreDiffCmd(clictx *cli.Context) error {
+	aPath, bPath := clictx.String("a"), clictx.String("b")
+	if aPath == "" || bPath == "" {
+		return errors.New("both --a and --b configs are required")
+	}
+
+	name := clictx.String("blobstore")
+
+	a, err := listerFromPath(name, aPath)
+	if err != nil {
+		return fmt.Errorf("a: %v", err)
+	}
+
+	b, err := listerFromPath(name, bPath)
+	if err != nil {
+		return fmt.Errorf("b: %v", err)
+	}
+
+	missingInB, missingInA, err := blobstore.Diff(context.Background(), a, b)
+	if err != nil {
+		return fmt.Errorf("diff: %v", err)
+	}
+
+	fmt.Printf("missing in b: %d\n", len(missingInB))
+	fmt.Printf("missing in a: %d\n", len(missingInA))
+
+	if clictx.Bool("print-missing") {
+		for _, ref := range missingInB {
+			fmt.Println("b", ref)
+		}
+		for _, ref := range missingInA {
+			fmt.Println("a", ref)
+		}
+	}
+
+	return nil
+}
+
+// listerFromPath opens the named blobstore of the config at path,
+// erroring if the blobstore cannot list its blobs.
+func listerFromPath(name, path string) (fixity.BlobLister, error) {
+	c, err := config.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open config: %v", err)
+	}
+
+	bs, err := fixity.NewBlobstoreFromConfig(name, c)
+	if err != nil {
+		return nil, fmt.Errorf("blobstore %q: %v", name, err)
+	}
+
+	l, ok := bs.(fixity.BlobLister)
+	if !ok {
+		return nil, fmt.Errorf("blobstore %q does not support listing", name)
+	}
+
+	return l, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/leeola/fixity"
+	"github.com/urfave/cli"
+)
+
+func ValidateCmd(clictx *cli.Context) error {
+	if len(clictx.Args()) == 0 {
+		return errors.New("missing hash arg")
+	}
+
+	s, err := storeFromCli(clictx)
+	if err != nil {
+		// no wrap above helper errs
+		return err
+	}
+
+	var invalid int
+	for _, sRef := range clictx.Args() {
+		ref, err := fixity.ParseRef(sRef)
+		if err != nil {
+			return err
+		}
+
+		rc, err := s.Blob(context.Background(), ref)
+		if err != nil {
+			return fmt.Errorf("blob %q: %v", ref, err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("readall %q: %v", ref, err)
+		}
+
+		if err := fixity.ValidateSchema(b); err != nil {
+			invalid++
+			fmt.Printf("%s: %v\n", ref, err)
+			continue
+		}
+
+		fmt.Printf("%s: valid\n", ref)
+	}
+
+	if invalid != 0 {
+		return fmt.Errorf("%d invalid blobs", invalid)
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/leeola/fixity"
 	"github.com/leeola/fixity/reader/blobreader"
+	"github.com/leeola/fixity/util/wutil"
 	"github.com/leeola/fixity/value"
 	"github.com/urfave/cli"
 )
@@ -39,13 +40,20 @@ func WriteCmd(clictx *cli.Context) error {
 	}
 
 	if useStdin {
-		return writeReadCloser(clictx, s, ioutil.NopCloser(os.Stdin), id)
+		if err := writeReadCloser(clictx, s, ioutil.NopCloser(os.Stdin), id, nil); err != nil {
+			return err // no wrap helper err
+		}
+	} else {
+		for _, filename := range filenames {
+			if err := writeFile(clictx, s, id, filename); err != nil {
+				return fmt.Errorf("writereadcloser %q: %v", filename, err)
+			}
+		}
 	}
 
-	for _, filename := range filenames {
-		if err := writeFile(clictx, s, id, filename); err != nil {
-			return fmt.Errorf("writereadcloser %q: %v", filename, err)
-		}
+	// flush the batch of writes, for stores configured to buffer them.
+	if err := s.Sync(); err != nil {
+		return fmt.Errorf("sync: %v", err)
 	}
 
 	return nil
@@ -67,7 +75,15 @@ func writeFile(clictx *cli.Context, s store, id, filename string) error {
 	}
 	defer f.Close()
 
-	if err := writeReadCloser(clictx, s, f, id); err != nil {
+	var fileValues fixity.Values
+	if clictx.Bool("file-values") {
+		fileValues, err = wutil.FileValues(f)
+		if err != nil {
+			return fmt.Errorf("filevalues %q: %v", filename, err)
+		}
+	}
+
+	if err := writeReadCloser(clictx, s, f, id, fileValues); err != nil {
 		return fmt.Errorf("writereadcloser %q: %v", filename, err)
 	}
 
@@ -78,7 +94,9 @@ func writeFile(clictx *cli.Context, s store, id, filename string) error {
 	return nil
 }
 
-func writeReadCloser(clictx *cli.Context, s store, r io.Reader, id string) error {
+// writeReadCloser writes the reader with the kv flag values, merged over
+// the given base values.
+func writeReadCloser(clictx *cli.Context, s store, r io.Reader, id string, base fixity.Values) error {
 	preview := clictx.Bool("preview")
 	allowUnsafe := clictx.Bool("allow-unsafe")
 
@@ -86,16 +104,9 @@ func writeReadCloser(clictx *cli.Context, s store, r io.Reader, id string) error
 		return errors.New("id must be defined if it cannot be inferred")
 	}
 
-	var values fixity.Values
-	for _, kv := range clictx.StringSlice("kv") {
-		if values == nil {
-			values = fixity.Values{}
-		}
-		k, v, err := splitKV(kv)
-		if err != nil {
-			return err // no wrap helper err
-		}
-		values[k] = value.String(v)
+	values, err := kvValues(clictx.StringSlice("kv"), base)
+	if err != nil {
+		return err // no wrap helper err
 	}
 
 	hashes, err := s.Write(context.Background(), id, values, r)
@@ -145,6 +156,40 @@ func previewBlob(ctx context.Context, s store, ref fixity.Ref, notSafe bool) err
 	return nil
 }
 
+// kvValues returns the key=value pairs as values, merged over the given
+// base values.
+//
+// Pairs replace base values, but repeated pair keys, such as tag=a and
+// tag=b, become a list.
+func kvValues(kvs []string, base fixity.Values) (fixity.Values, error) {
+	var values fixity.Values
+	if len(base) > 0 {
+		values = fixity.Values{}
+		for k, v := range base {
+			values[k] = v
+		}
+	}
+
+	kvKeys := map[string]bool{}
+	for _, kv := range kvs {
+		if values == nil {
+			values = fixity.Values{}
+		}
+		k, v, err := splitKV(kv)
+		if err != nil {
+			return nil, err // no wrap helper err
+		}
+		if kvKeys[k] {
+			values[k] = values[k].Append(value.String(v))
+		} else {
+			values[k] = value.String(v)
+		}
+		kvKeys[k] = true
+	}
+
+	return values, nil
+}
+
 func splitKV(kv string) (string, string, error) {
 	split := strings.SplitN(kv, "=", 2)
 	if len(split) != 2 {
@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/leeola/fixity/config/log"
@@ -86,24 +86,56 @@ func (c Config) MarshalInterfaces() (Config, error) {
 	return c, nil
 }
 
+// Open loads the config at path, resolving any includes.
+//
+// A config may include other config files with a top level include
+// directive, such as:
+//
+//	{"include": ["secrets.json", "peers.json"]}
+//
+// Relative include paths are resolved from the directory of the
+// including file. Included files are merged over the including file in
+// the order listed, so later files override earlier ones. Objects, such
+// as storeConfigs and their nested config, are merged key by key, while
+// all other values are replaced. Includes may themselves include files,
+// but circular includes are an error.
+//
+// After merging, string values may reference environment variables with
+// ${VAR}, or ${VAR:-default} to fall back when VAR is unset or empty.
+// Referencing an unset variable without a default is an error.
 func Open(path string) (Config, error) {
+	return open(path, true)
+}
+
+// OpenRaw opens the config at path as Open does, but leaves ${VAR}
+// references as written rather than interpolating the environment.
+//
+// Useful for persisting a config without the values, often secrets,
+// of the environment it was opened in.
+func OpenRaw(path string) (Config, error) {
+	return open(path, false)
+}
+
+func open(path string, interpolate bool) (Config, error) {
 	path, err := homedir.Expand(path)
 	if err != nil {
 		return Config{}, fmt.Errorf("expand: %v", err)
 	}
 
-	f, err := os.OpenFile(path, os.O_RDONLY, 0644)
-	if perr, ok := err.(*os.PathError); ok && perr.Err == syscall.ENOENT {
-		return Config{}, ErrNotExist
-	}
+	m, err := openMerged(path, nil)
 	if err != nil {
-		return Config{}, fmt.Errorf("open: %v", err)
+		return Config{}, err // no wrap, to allow ErrNotExist comparisons
 	}
-	defer f.Close()
 
-	b, err := ioutil.ReadAll(f)
+	if interpolate {
+		if _, err := interpolateEnv(m); err != nil {
+			return Config{}, fmt.Errorf("interpolate env: %v", err)
+		}
+	}
+
+	b, err := json.Marshal(m)
 	if err != nil {
-		return Config{}, fmt.Errorf("read: %v", err)
+		return Config{}, fmt.Errorf("marshal merged: %v", err)
 	}
 
 	var c Config
@@ -114,6 +146,107 @@ func Open(path string) (Config, error) {
 	return c, nil
 }
 
+// openMerged reads the json object at path, merging any includes into
+// it. stack is the chain of files including this one.
+func openMerged(path string, stack []string) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("abs: %v", err)
+	}
+
+	for _, p := range stack {
+		if p == absPath {
+			return nil, fmt.Errorf("circular include: %s",
+				strings.Join(append(stack, absPath), " -> "))
+		}
+	}
+	stack = append(stack, absPath)
+
+	f, err := os.OpenFile(absPath, os.O_RDONLY, 0644)
+	if perr, ok := err.(*os.PathError); ok && perr.Err == syscall.ENOENT {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open: %v", err)
+	}
+	defer f.Close()
+
+	// UseNumber to avoid float conversions of large integers.
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %v", absPath, err)
+	}
+
+	includes, err := popIncludes(m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", absPath, err)
+	}
+
+	for _, inc := range includes {
+		incPath, err := homedir.Expand(inc)
+		if err != nil {
+			return nil, fmt.Errorf("expand include %s: %v", inc, err)
+		}
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(absPath), incPath)
+		}
+
+		im, err := openMerged(incPath, stack)
+		if err == ErrNotExist {
+			return nil, fmt.Errorf("include %s: %v", incPath, err)
+		}
+		if err != nil {
+			return nil, err // no wrap, already contains path context
+		}
+
+		mergeObjects(m, im)
+	}
+
+	return m, nil
+}
+
+// popIncludes removes and returns the include directive of the object.
+func popIncludes(m map[string]interface{}) ([]string, error) {
+	v, ok := m["include"]
+	if !ok {
+		return nil, nil
+	}
+	delete(m, "include")
+
+	ifcs, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("include must be a list of paths")
+	}
+
+	includes := make([]string, len(ifcs))
+	for i, ifc := range ifcs {
+		s, ok := ifc.(string)
+		if !ok {
+			return nil, fmt.Errorf("include %d is not a path string", i)
+		}
+		includes[i] = s
+	}
+
+	return includes, nil
+}
+
+// mergeObjects merges src into dst, recursing into objects present in
+// both and replacing all other values.
+func mergeObjects(dst, src map[string]interface{}) {
+	for k, sv := range src {
+		srcObj, srcIsObj := sv.(map[string]interface{})
+		dstObj, dstIsObj := dst[k].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			mergeObjects(dstObj, srcObj)
+			continue
+		}
+		dst[k] = sv
+	}
+}
+
 func Save(path string, c Config) error {
 	path, err := homedir.Expand(path)
 	if err != nil {
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "fixity-config")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("writefile %s: %v", name, err)
+		}
+	}
+
+	return dir
+}
+
+func TestOpenInclude(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"base.json": `{
+			"include": ["secrets.json"],
+			"rootPath": "base-root",
+			"store": "base-store",
+			"storeConfigs": {
+				"default": {"type": "nosign", "config": {"blobstoreName": "default"}}
+			}
+		}`,
+		"secrets.json": `{
+			"store": "secret-store",
+			"storeConfigs": {
+				"default": {"config": {"password": "hunter2"}}
+			}
+		}`,
+	})
+	defer os.RemoveAll(dir)
+
+	c, err := Open(filepath.Join(dir, "base.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if c.RootPath != "base-root" {
+		t.Errorf("rootPath want:base-root, got:%s", c.RootPath)
+	}
+	if c.Store != "secret-store" {
+		t.Errorf("store want:secret-store, got:%s", c.Store)
+	}
+
+	tc := c.StoreConfigs["default"]
+	if tc.Type != "nosign" {
+		t.Errorf("store type want:nosign, got:%s", tc.Type)
+	}
+
+	var storeConfig map[string]string
+	if err := json.Unmarshal(tc.Config, &storeConfig); err != nil {
+		t.Fatalf("unmarshal store config: %v", err)
+	}
+	if storeConfig["blobstoreName"] != "default" {
+		t.Errorf("blobstoreName want:default, got:%s", storeConfig["blobstoreName"])
+	}
+	if storeConfig["password"] != "hunter2" {
+		t.Errorf("password want:hunter2, got:%s", storeConfig["password"])
+	}
+}
+
+func TestOpenCircularInclude(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"a.json": `{"include": ["b.json"]}`,
+		"b.json": `{"include": ["a.json"]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := Open(filepath.Join(dir, "a.json"))
+	if err == nil || !strings.Contains(err.Error(), "circular include") {
+		t.Errorf("want circular include error, got:%v", err)
+	}
+}
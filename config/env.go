@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// interpolateEnv replaces ${VAR} and ${VAR:-default} references in every
+// string value of the json object, recursing into objects and lists.
+//
+// A ${VAR} reference to an unset variable is an error, while
+// ${VAR:-default} falls back to default when VAR is unset or empty.
+func interpolateEnv(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		return expandEnv(t)
+	case map[string]interface{}:
+		for k, mv := range t {
+			iv, err := interpolateEnv(mv)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			t[k] = iv
+		}
+		return t, nil
+	case []interface{}:
+		for i, lv := range t {
+			iv, err := interpolateEnv(lv)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", i, err)
+			}
+			t[i] = iv
+		}
+		return t, nil
+	default:
+		return v, nil
+	}
+}
+
+// expandEnv expands the env references within a single string.
+func expandEnv(s string) (string, error) {
+	var (
+		buf  strings.Builder
+		rest = s
+	)
+	for {
+		start := strings.Index(rest, "${")
+		if start == -1 {
+			buf.WriteString(rest)
+			return buf.String(), nil
+		}
+
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			return "", fmt.Errorf("unterminated env reference: %q", s)
+		}
+		end += start
+
+		val, err := lookupEnv(rest[start+2 : end])
+		if err != nil {
+			return "", err
+		}
+
+		buf.WriteString(rest[:start])
+		buf.WriteString(val)
+		rest = rest[end+1:]
+	}
+}
+
+// lookupEnv resolves the body of a single ${...} reference.
+func lookupEnv(ref string) (string, error) {
+	name, def, hasDefault := ref, "", false
+	if i := strings.Index(ref, ":-"); i != -1 {
+		name, def, hasDefault = ref[:i], ref[i+2:], true
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("empty env reference")
+	}
+
+	val, ok := os.LookupEnv(name)
+	if hasDefault && val == "" {
+		return def, nil
+	}
+	if !ok {
+		return "", fmt.Errorf("env var not set: %s", name)
+	}
+
+	return val, nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenEnv(t *testing.T) {
+	os.Setenv("FIXITY_TEST_ROOT", "/env/root")
+	defer os.Unsetenv("FIXITY_TEST_ROOT")
+	os.Unsetenv("FIXITY_TEST_UNSET")
+
+	dir := writeTestFiles(t, map[string]string{
+		"config.json": `{
+			"rootPath": "${FIXITY_TEST_ROOT}/fixity",
+			"store": "${FIXITY_TEST_UNSET:-default}"
+		}`,
+		"missing.json": `{"rootPath": "${FIXITY_TEST_UNSET}"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	c, err := Open(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if c.RootPath != "/env/root/fixity" {
+		t.Errorf("rootPath want:/env/root/fixity, got:%s", c.RootPath)
+	}
+	if c.Store != "default" {
+		t.Errorf("store want:default, got:%s", c.Store)
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("want error for unset env var")
+	}
+
+	raw, err := OpenRaw(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("openraw: %v", err)
+	}
+	if raw.RootPath != "${FIXITY_TEST_ROOT}/fixity" {
+		t.Errorf("raw rootPath want:${FIXITY_TEST_ROOT}/fixity, got:%s", raw.RootPath)
+	}
+	if _, err := OpenRaw(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("openraw unset env var: %v", err)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("FIXITY_TEST_A", "a")
+	defer os.Unsetenv("FIXITY_TEST_A")
+	os.Unsetenv("FIXITY_TEST_UNSET")
+
+	testCases := []struct {
+		in, want string
+		err      bool
+	}{
+		{in: "plain", want: "plain"},
+		{in: "${FIXITY_TEST_A}", want: "a"},
+		{in: "x-${FIXITY_TEST_A}-${FIXITY_TEST_A}", want: "x-a-a"},
+		{in: "${FIXITY_TEST_A:-b}", want: "a"},
+		{in: "${FIXITY_TEST_UNSET:-b}", want: "b"},
+		{in: "${FIXITY_TEST_UNSET:-}", want: ""},
+		{in: "${FIXITY_TEST_UNSET}", err: true},
+		{in: "${FIXITY_TEST_A", err: true},
+		{in: "${}", err: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := expandEnv(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q want error, got:%q", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q want:%q, got:%q", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// RedactedValue replaces the value of secret fields in a redacted config.
+const RedactedValue = "[redacted]"
+
+var (
+	configTypes   = map[string]reflect.Type{}
+	configTypesMu sync.Mutex
+)
+
+// RegisterConfigType registers the config struct of the given type name,
+// allowing fields tagged with `secret:"true"` to be redacted from the raw
+// json of TypeConfigs of that type.
+//
+// The built in blobstore, index and store types register their configs,
+// though none currently have secret fields, as they only configure
+// local paths and tuning. Redacting their configs is a no-op until a
+// secret field is added.
+func RegisterConfigType(typ string, v interface{}) {
+	configTypesMu.Lock()
+	defer configTypesMu.Unlock()
+
+	rt := reflect.TypeOf(v)
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	configTypes[typ] = rt
+}
+
+// Redacted returns a copy of the config with the value of every field
+// tagged `secret:"true"` replaced by RedactedValue.
+//
+// TypeConfig secrets are found from the ConfigInterface if set, or from
+// the struct registered with RegisterConfigType for the config type.
+// Configs of unregistered types are returned unmodified.
+func (c Config) Redacted() (Config, error) {
+	configs := map[string]map[string]TypeConfig{
+		"blobstore": c.BlobstoreConfigs,
+		"index":     c.IndexConfigs,
+		"store":     c.StoreConfigs,
+	}
+
+	redacted := map[string]map[string]TypeConfig{}
+	for configGroupName, configGroup := range configs {
+		if configGroup == nil {
+			continue
+		}
+
+		group := make(map[string]TypeConfig, len(configGroup))
+		for k, tc := range configGroup {
+			rtc, err := tc.redacted()
+			if err != nil {
+				return Config{}, fmt.Errorf("redact %s config %q: %v", configGroupName, k, err)
+			}
+			group[k] = rtc
+		}
+		redacted[configGroupName] = group
+	}
+
+	c.BlobstoreConfigs = redacted["blobstore"]
+	c.IndexConfigs = redacted["index"]
+	c.StoreConfigs = redacted["store"]
+
+	return c, nil
+}
+
+func (tc TypeConfig) redacted() (TypeConfig, error) {
+	var rt reflect.Type
+	if tc.ConfigInterface != nil {
+		rt = reflect.TypeOf(tc.ConfigInterface)
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+
+		b, err := json.Marshal(tc.ConfigInterface)
+		if err != nil {
+			return TypeConfig{}, fmt.Errorf("marshal: %v", err)
+		}
+		tc.Config = b
+		tc.ConfigInterface = nil
+	} else {
+		configTypesMu.Lock()
+		rt = configTypes[tc.Type]
+		configTypesMu.Unlock()
+	}
+
+	if rt == nil || rt.Kind() != reflect.Struct || len(tc.Config) == 0 {
+		return tc, nil
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(tc.Config, &m); err != nil {
+		return TypeConfig{}, fmt.Errorf("unmarshal: %v", err)
+	}
+
+	redactStruct(rt, m)
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return TypeConfig{}, fmt.Errorf("marshal: %v", err)
+	}
+	tc.Config = b
+
+	return tc, nil
+}
+
+// redactStruct replaces the secret fields of rt within m, recursing into
+// nested structs.
+func redactStruct(rt reflect.Type, m map[string]interface{}) {
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		name := fieldName(sf)
+		v, ok := m[name]
+		if !ok {
+			continue
+		}
+
+		if sf.Tag.Get("secret") == "true" {
+			m[name] = RedactedValue
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if nested, ok := v.(map[string]interface{}); ok && ft.Kind() == reflect.Struct {
+			redactStruct(ft, nested)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type redactTestConfig struct {
+	Host     string `json:"host"`
+	Password string `json:"password" secret:"true"`
+	Nested   struct {
+		Token string `json:"token" secret:"true"`
+	} `json:"nested"`
+}
+
+func TestRedacted(t *testing.T) {
+	RegisterConfigType("redacttest", redactTestConfig{})
+
+	os.Setenv("FIXITY_TEST_PASSWORD", "hunter2")
+	defer os.Unsetenv("FIXITY_TEST_PASSWORD")
+
+	dir := writeTestFiles(t, map[string]string{
+		"config.json": `{
+			"rootPath": "root",
+			"storeConfigs": {
+				"default": {"type": "redacttest", "config": {
+					"host": "example.com",
+					"password": "${FIXITY_TEST_PASSWORD}",
+					"nested": {"token": "abc"}
+				}}
+			}
+		}`,
+	})
+	defer os.RemoveAll(dir)
+
+	c, err := Open(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	rc, err := c.Redacted()
+	if err != nil {
+		t.Fatalf("redacted: %v", err)
+	}
+
+	if rc.RootPath != "root" {
+		t.Errorf("rootPath want:root, got:%s", rc.RootPath)
+	}
+
+	var got redactTestConfig
+	if err := rc.StoreConfig("default", &got); err != nil {
+		t.Fatalf("storeconfig: %v", err)
+	}
+	if got.Host != "example.com" {
+		t.Errorf("host want:example.com, got:%s", got.Host)
+	}
+	if got.Password != RedactedValue {
+		t.Errorf("password want:%s, got:%s", RedactedValue, got.Password)
+	}
+	if got.Nested.Token != RedactedValue {
+		t.Errorf("token want:%s, got:%s", RedactedValue, got.Nested.Token)
+	}
+
+	// the original config must be left unmodified.
+	var orig redactTestConfig
+	if err := json.Unmarshal(c.StoreConfigs["default"].Config, &orig); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if orig.Password != "hunter2" {
+		t.Errorf("original password want:hunter2, got:%s", orig.Password)
+	}
+}
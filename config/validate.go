@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a single invalid config field.
+type FieldError struct {
+	// Field is the json name of the field, dot separated for nested
+	// structs.
+	Field string
+	Msg   string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Msg)
+}
+
+// ValidationErrors aggregates every invalid field of a config, so that
+// all problems can be reported at once.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks the `validate` struct tags of the given struct,
+// returning ValidationErrors if any fields are invalid.
+//
+// Supported comma separated rules:
+//
+//	required   the field must not be the zero value.
+//	min=N      numbers must be >= N, strings and slices must have len >= N.
+//	max=N      numbers must be <= N, strings and slices must have len <= N.
+//
+// Nested structs are validated recursively.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("cannot validate nil pointer")
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot validate non-struct: %s", rv.Kind())
+	}
+
+	var errs ValidationErrors
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		fv := rv.Field(i)
+		name := prefix + fieldName(sf)
+
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				msg, err := checkRule(fv, rule)
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+				if msg != "" {
+					*errs = append(*errs, FieldError{Field: name, Msg: msg})
+				}
+			}
+		}
+
+		if fv.Kind() == reflect.Struct {
+			if err := validateStruct(fv, name+".", errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRule returns a message describing the failure of rule, or an
+// empty string if the value satisfies it.
+func checkRule(fv reflect.Value, rule string) (string, error) {
+	split := strings.SplitN(rule, "=", 2)
+	switch split[0] {
+	case "required":
+		if isZero(fv) {
+			return "is required", nil
+		}
+		return "", nil
+	case "min", "max":
+		if len(split) != 2 {
+			return "", fmt.Errorf("rule %q missing value", rule)
+		}
+		bound, err := strconv.ParseFloat(split[1], 64)
+		if err != nil {
+			return "", fmt.Errorf("rule %q: %v", rule, err)
+		}
+
+		n, isLen, ok := numeric(fv)
+		if !ok {
+			return "", fmt.Errorf("rule %q unsupported for %s", rule, fv.Kind())
+		}
+
+		what := "must be"
+		if isLen {
+			what = "length must be"
+		}
+
+		if split[0] == "min" && n < bound {
+			return fmt.Sprintf("%s at least %s", what, split[1]), nil
+		}
+		if split[0] == "max" && n > bound {
+			return fmt.Sprintf("%s at most %s", what, split[1]), nil
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown rule: %q", rule)
+	}
+}
+
+// numeric returns the comparable number of the value, and whether that
+// number is a length.
+func numeric(fv reflect.Value) (n float64, isLen, ok bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false, true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(fv.Len()), true, true
+	default:
+		return 0, false, false
+	}
+}
+
+func isZero(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	default:
+		return reflect.DeepEqual(fv.Interface(), reflect.Zero(fv.Type()).Interface())
+	}
+}
+
+// fieldName returns the json name of the field, falling back to the Go
+// name, as users write configs in json.
+func fieldName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,54 @@
+package config
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	type nested struct {
+		Workers int `json:"workers" validate:"min=1,max=64"`
+	}
+	type testConfig struct {
+		Path   string `json:"path" validate:"required"`
+		Name   string `json:"name,omitempty" validate:"max=4"`
+		Nested nested `json:"nested"`
+	}
+
+	testCases := []struct {
+		Config    testConfig
+		ExpectErr string
+	}{
+		{
+			Config: testConfig{Path: "foo", Nested: nested{Workers: 1}},
+		},
+		{
+			Config:    testConfig{Nested: nested{Workers: 1}},
+			ExpectErr: "path: is required",
+		},
+		{
+			Config:    testConfig{Path: "foo", Name: "toolong"},
+			ExpectErr: "name: length must be at most 4; nested.workers: must be at least 1",
+		},
+		{
+			Config:    testConfig{Nested: nested{Workers: 100}},
+			ExpectErr: "path: is required; nested.workers: must be at most 64",
+		},
+	}
+	for i, testCase := range testCases {
+		err := Validate(testCase.Config)
+		if testCase.ExpectErr == "" {
+			if err != nil {
+				t.Errorf("case %d unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("case %d want:%q, got nil", i, testCase.ExpectErr)
+			continue
+		}
+		if _, ok := err.(ValidationErrors); !ok {
+			t.Errorf("case %d want ValidationErrors, got %T", i, err)
+		}
+		if err.Error() != testCase.ExpectErr {
+			t.Errorf("case %d want:%q, got:%q", i, testCase.ExpectErr, err.Error())
+		}
+	}
+}
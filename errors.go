@@ -0,0 +1,62 @@
+package fixity
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrReadOnly is returned by writes to a store configured as read only.
+	ErrReadOnly = errors.New("read only")
+
+	// ErrConstraintViolation is returned by conditional writes when the
+	// write's constraint is not satisfied, such as an existing match for
+	// a query which was required to be absent.
+	ErrConstraintViolation = errors.New("constraint violation")
+
+	// ErrRefMismatch is the sentinel of RefMismatchError, returned when
+	// content does not hash to the ref it was stored or read as.
+	ErrRefMismatch = errors.New("content does not match ref")
+
+	// ErrChainCycle is returned when following a chain of blobs, such as
+	// MoreParts, revisits a blob. Content addressing makes cycles
+	// impossible for honest writers, so a cycle indicates a corrupt or
+	// malicious blobstore.
+	ErrChainCycle = errors.New("blob chain cycle")
+)
+
+// RefError records the ref of a failed blob operation, such as a read
+// of a missing blob wrapping os.ErrNotExist.
+//
+// Use errors.Is to compare the underlying error, and errors.As to
+// retrieve the ref.
+type RefError struct {
+	Op  string
+	Ref Ref
+	Err error
+}
+
+func (e *RefError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Ref, e.Err)
+}
+
+func (e *RefError) Unwrap() error {
+	return e.Err
+}
+
+// RefMismatchError records the expected and actual refs of content
+// which did not hash to the expected ref.
+//
+// errors.Is(err, ErrRefMismatch) matches all RefMismatchErrors.
+type RefMismatchError struct {
+	Expected Ref
+	Actual   Ref
+}
+
+func (e *RefMismatchError) Error() string {
+	return fmt.Sprintf("%v: expected %s, got %s", ErrRefMismatch, e.Expected, e.Actual)
+}
+
+func (e *RefMismatchError) Unwrap() error {
+	return ErrRefMismatch
+}
@@ -0,0 +1,43 @@
+package fixity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestRefError(t *testing.T) {
+	ref := Ref("foo")
+	err := fmt.Errorf("wrapped: %w", &RefError{Op: "read", Ref: ref, Err: os.ErrNotExist})
+
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("errors.Is want:%v, got:%v", os.ErrNotExist, err)
+	}
+
+	var refErr *RefError
+	if !errors.As(err, &refErr) {
+		t.Fatalf("errors.As want RefError, got:%T", err)
+	}
+	if refErr.Ref != ref {
+		t.Errorf("ref want:%s, got:%s", ref, refErr.Ref)
+	}
+}
+
+func TestRefMismatchError(t *testing.T) {
+	expected, actual := Ref("foo"), Ref("bar")
+	err := fmt.Errorf("wrapped: %w", &RefMismatchError{Expected: expected, Actual: actual})
+
+	if !errors.Is(err, ErrRefMismatch) {
+		t.Errorf("errors.Is want:%v, got:%v", ErrRefMismatch, err)
+	}
+
+	var mismatch *RefMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("errors.As want RefMismatchError, got:%T", err)
+	}
+	if mismatch.Expected != expected || mismatch.Actual != actual {
+		t.Errorf("mismatch want:%s/%s, got:%s/%s",
+			expected, actual, mismatch.Expected, mismatch.Actual)
+	}
+}
@@ -2,6 +2,8 @@ package fixity
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/leeola/fixity/config"
@@ -44,12 +46,23 @@ func NewFromPath(storeName string, configPath string) (Store, error) {
 	return NewFromConfig(storeName, c)
 }
 
+// NewFromConfig constructs the named store from the registered store
+// constructors.
+//
+// If storeName is empty the config Store is used, and if that is also
+// empty the single configured store is used. Importing a package which
+// registers a store constructor is all that is needed to make its type
+// available.
 func NewFromConfig(storeName string, c config.Config) (Store, error) {
 	if storeName == "" {
 		storeName = c.Store
 	}
 	if storeName == "" {
-		return nil, fmt.Errorf("missing required argument: storeName")
+		name, err := soleStoreName(c)
+		if err != nil {
+			return nil, err
+		}
+		storeName = name
 	}
 
 	tc, ok := c.StoreConfigs[storeName]
@@ -69,3 +82,25 @@ func NewFromConfig(storeName string, c config.Config) (Store, error) {
 
 	return s, nil
 }
+
+// soleStoreName returns the name of the only store config, erroring if
+// there are zero or multiple to choose from.
+func soleStoreName(c config.Config) (string, error) {
+	switch len(c.StoreConfigs) {
+	case 0:
+		return "", fmt.Errorf("no store configured")
+	case 1:
+		for name := range c.StoreConfigs {
+			return name, nil
+		}
+	}
+
+	names := make([]string, 0, len(c.StoreConfigs))
+	for name := range c.StoreConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return "", fmt.Errorf("multiple stores configured, specify one of: %s",
+		strings.Join(names, ", "))
+}
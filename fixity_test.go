@@ -0,0 +1,80 @@
+package fixity
+
+import (
+	"testing"
+
+	"github.com/leeola/fixity/config"
+)
+
+type fakeStore struct {
+	Store
+	name string
+}
+
+func init() {
+	fakeConstructor := StoreConstructorFunc(func(name string, _ config.Config) (Store, error) {
+		return fakeStore{name: name}, nil
+	})
+	RegisterStore("test-fake-a", fakeConstructor)
+	RegisterStore("test-fake-b", fakeConstructor)
+}
+
+func TestNewFromConfigSelection(t *testing.T) {
+
+	testCases := []struct {
+		StoreName    string
+		Configs      map[string]config.TypeConfig
+		ExpectStore  string
+		ExpectErrMsg string
+	}{
+		{
+			Configs: map[string]config.TypeConfig{
+				"a": {Type: "test-fake-a"},
+			},
+			ExpectStore: "a",
+		},
+		{
+			Configs: map[string]config.TypeConfig{
+				"b": {Type: "test-fake-b"},
+			},
+			ExpectStore: "b",
+		},
+		{
+			StoreName: "b",
+			Configs: map[string]config.TypeConfig{
+				"a": {Type: "test-fake-a"},
+				"b": {Type: "test-fake-b"},
+			},
+			ExpectStore: "b",
+		},
+		{
+			Configs: map[string]config.TypeConfig{
+				"a": {Type: "test-fake-a"},
+				"b": {Type: "test-fake-b"},
+			},
+			ExpectErrMsg: "multiple stores configured, specify one of: a, b",
+		},
+		{
+			Configs:      map[string]config.TypeConfig{},
+			ExpectErrMsg: "no store configured",
+		},
+	}
+	for i, testCase := range testCases {
+		s, err := NewFromConfig(testCase.StoreName, config.Config{
+			StoreConfigs: testCase.Configs,
+		})
+		if testCase.ExpectErrMsg != "" {
+			if err == nil || err.Error() != testCase.ExpectErrMsg {
+				t.Errorf("case %d want err:%q, got:%v", i, testCase.ExpectErrMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d unexpected error: %v", i, err)
+			continue
+		}
+		if name := s.(fakeStore).name; name != testCase.ExpectStore {
+			t.Errorf("case %d store want:%s, got:%s", i, testCase.ExpectStore, name)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Package fixityfs exposes the latest data of fixity ids as an fs.FS,
+// treating slash separated ids such as "project/file.txt" as paths.
+//
+// This allows io/fs tooling, such as fs.WalkDir and http.FS, to browse
+// a store. Directories are implied by id prefixes, and ids which are not
+// valid fs paths, such as those with a leading slash, are not visible.
+//
+// An id which is also a directory, such as "a" alongside "a/b", is a
+// directory everywhere, and the data of the id "a" is not visible.
+//
+// Listing a directory queries every id under it, recursively, so
+// listing "." scans every id of the index.
+//
+// Modification times are the mutation time, which is only known once a
+// file is opened, so directory entries have zero modification times.
+package fixityfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/index"
+	"github.com/leeola/fixity/q"
+)
+
+// pageSize is the number of ids queried at once when listing.
+const pageSize = 100
+
+// Store is the subset of fixity.Store needed to list and read ids.
+type Store interface {
+	fixity.Querier
+	Read(ctx context.Context, id string) (fixity.Mutation, fixity.Values, fixity.Reader, error)
+}
+
+// FS is a read only fs.FS of the ids of a Store.
+type FS struct {
+	ctx context.Context
+	s   Store
+}
+
+var (
+	_ fs.FS        = (*FS)(nil)
+	_ fs.ReadDirFS = (*FS)(nil)
+)
+
+func New(ctx context.Context, s Store) *FS {
+	return &FS{ctx: ctx, s: s}
+}
+
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	// directories take precedence over an id of the same name, as in
+	// readDir.
+	entries, err := fsys.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	if name == "." || len(entries) != 0 {
+		return &dir{name: name, entries: entries}, nil
+	}
+
+	matches, err := fsys.s.Query(q.New().Const(q.Id(name)).Limit(1))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	// any name can be a prefix, so only ids and prefixes of ids exist.
+	if len(matches) == 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	m, _, r, err := fsys.s.Read(fsys.ctx, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &file{name: name, mutation: m, r: r}, nil
+}
+
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := fsys.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	if name != "." && len(entries) == 0 {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return entries, nil
+}
+
+// readDir lists the ids prefixed by the directory name, returning the
+// files and directories directly within it, sorted by name.
+//
+// The index has no query for only the direct children of a prefix, so
+// every id under the directory is paged through, recursively.
+func (fsys *FS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+
+	byName := map[string]fs.DirEntry{}
+	for skip := 0; ; skip += pageSize {
+		qu := q.New().Const(q.IdPrefix(prefix)).
+			WithFields().
+			Skip(skip).
+			Limit(pageSize)
+
+		matches, err := fsys.s.Query(qu)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range matches {
+			// ids which are not valid paths are not visible, nor are
+			// the directories they would imply.
+			if !fs.ValidPath(m.ID) {
+				continue
+			}
+
+			rest := strings.TrimPrefix(m.ID, prefix)
+			child := rest
+			isDir := false
+			if i := strings.Index(rest, "/"); i != -1 {
+				child, isDir = rest[:i], true
+			}
+
+			if !fs.ValidPath(prefix+child) || prefix+child == "." {
+				continue
+			}
+
+			// a file and directory of the same name cannot both be
+			// represented, so the directory takes precedence, as in
+			// Open.
+			if _, ok := byName[child]; ok && !isDir {
+				continue
+			}
+
+			info := fileInfo{name: child, dir: isDir}
+			if !isDir {
+				info.size = fieldSize(m.Fields)
+			}
+			byName[child] = fs.FileInfoToDirEntry(info)
+		}
+
+		if len(matches) < pageSize {
+			break
+		}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(byName))
+	for _, e := range byName {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// fieldSize returns the indexed data size of a match, if any.
+func fieldSize(fields map[string]interface{}) int64 {
+	switch size := fields[index.FSizeKey].(type) {
+	case float64:
+		return int64(size)
+	case int64:
+		return size
+	case int:
+		return int64(size)
+	default:
+		return 0
+	}
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// file is the data of an id. Ids without data are empty files.
+type file struct {
+	name     string
+	mutation fixity.Mutation
+	r        fixity.Reader
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	info := fileInfo{
+		name:    baseName(f.name),
+		modTime: f.mutation.Time,
+	}
+
+	if f.r != nil {
+		size, err := f.r.Size()
+		if err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: f.name, Err: err}
+		}
+		info.size = size
+	}
+
+	return info, nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.r == nil {
+		return 0, io.EOF
+	}
+	return f.r.Read(p)
+}
+
+// Seek is supported for data readers, allowing http.FS to serve ranges.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.r == nil {
+		return 0, nil
+	}
+
+	seeker, ok := f.r.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("seek not supported")}
+	}
+	return seeker.Seek(offset, whence)
+}
+
+func (f *file) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	return f.r.Close()
+}
+
+// dir is an open directory, listed when opened.
+type dir struct {
+	name    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: baseName(d.name), dir: true}, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+func baseName(name string) string {
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
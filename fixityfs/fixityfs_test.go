@@ -0,0 +1,208 @@
+package fixityfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/index"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/q/operator"
+)
+
+// mapStore is a Store of id to data, supporting only the id queries
+// made by FS.
+type mapStore map[string]string
+
+func (s mapStore) Query(qu q.Query) ([]fixity.Match, error) {
+	c := qu.Constraint
+	if c.Field == nil || *c.Field != index.FIDKey {
+		return nil, errors.New("unsupported query")
+	}
+
+	var ids []string
+	for id := range s {
+		switch c.Operator {
+		case operator.Equal:
+			if id == c.Value.StringValue {
+				ids = append(ids, id)
+			}
+		case operator.Prefix:
+			if strings.HasPrefix(id, c.Value.StringValue) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+
+	if qu.SkipBy >= len(ids) {
+		return nil, nil
+	}
+	ids = ids[qu.SkipBy:]
+	if len(ids) > qu.LimitBy {
+		ids = ids[:qu.LimitBy]
+	}
+
+	matches := make([]fixity.Match, len(ids))
+	for i, id := range ids {
+		matches[i] = fixity.Match{ID: id, Ref: fixity.Ref("ref-" + id)}
+		if qu.IncludeFields {
+			matches[i].Fields = map[string]interface{}{
+				index.FSizeKey: float64(len(s[id])),
+			}
+		}
+	}
+	return matches, nil
+}
+
+func (s mapStore) Read(_ context.Context, id string) (fixity.Mutation, fixity.Values, fixity.Reader, error) {
+	data, ok := s[id]
+	if !ok {
+		return fixity.Mutation{}, nil, nil, errors.New("id not found")
+	}
+	return fixity.Mutation{ID: id}, nil, bytesReader{bytes.NewReader([]byte(data))}, nil
+}
+
+type bytesReader struct {
+	*bytes.Reader
+}
+
+func (r bytesReader) Size() (int64, error)      { return r.Reader.Size(), nil }
+func (r bytesReader) Checksum() (string, error) { return "", nil }
+func (r bytesReader) Close() error              { return nil }
+
+func TestFS(t *testing.T) {
+	s := mapStore{
+		"a.txt":          "a",
+		"dir/b.txt":      "bb",
+		"dir/sub/c.txt":  "ccc",
+		"/leading-slash": "invalid",
+	}
+	// enough ids to require paging.
+	for i := 0; i < pageSize+1; i++ {
+		s["many/"+strings.Repeat("x", i+1)] = "x"
+	}
+
+	fsys := New(context.Background(), s)
+
+	var walked []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(path, "many/") {
+			walked = append(walked, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkdir: %v", err)
+	}
+
+	expectWalk := []string{".", "a.txt", "dir", "dir/b.txt", "dir/sub", "dir/sub/c.txt", "many"}
+	if !reflect.DeepEqual(walked, expectWalk) {
+		t.Errorf("walk want:%v, got:%v", expectWalk, walked)
+	}
+
+	many, err := fs.ReadDir(fsys, "many")
+	if err != nil {
+		t.Fatalf("readdir many: %v", err)
+	}
+	if len(many) != pageSize+1 {
+		t.Errorf("paged readdir want:%d, got:%d", pageSize+1, len(many))
+	}
+
+	testCases := []struct {
+		Path   string
+		Expect string
+		Err    bool
+	}{
+		{Path: "a.txt", Expect: "a"},
+		{Path: "dir/b.txt", Expect: "bb"},
+		{Path: "dir/sub/c.txt", Expect: "ccc"},
+		{Path: "missing.txt", Err: true},
+		{Path: "/leading-slash", Err: true},
+	}
+
+	for _, tc := range testCases {
+		b, err := fs.ReadFile(fsys, tc.Path)
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%s want error", tc.Path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s readfile: %v", tc.Path, err)
+			continue
+		}
+		if string(b) != tc.Expect {
+			t.Errorf("%s want:%q, got:%q", tc.Path, tc.Expect, b)
+		}
+	}
+
+	info, err := fs.Stat(fsys, "dir/sub/c.txt")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Name() != "c.txt" || info.Size() != 3 || info.IsDir() {
+		t.Errorf("stat want c.txt size 3, got:%s size %d dir:%t", info.Name(), info.Size(), info.IsDir())
+	}
+
+	if _, err := fs.Stat(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing want ErrNotExist, got:%v", err)
+	}
+}
+
+func TestFSFileAndDir(t *testing.T) {
+	s := mapStore{
+		"a":       "file",
+		"a/b":     "b",
+		"x//y":    "invalid",
+		"x":       "x",
+		"x/../up": "invalid",
+	}
+	fsys := New(context.Background(), s)
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatalf("readdir: %v", err)
+	}
+
+	// the invalid ids under x imply no directory, so x remains a file.
+	expectDir := map[string]bool{"a": true, "x": false}
+	if len(entries) != len(expectDir) {
+		t.Errorf("readdir want:%d entries, got:%v", len(expectDir), entries)
+	}
+	for _, e := range entries {
+		if isDir, ok := expectDir[e.Name()]; !ok || e.IsDir() != isDir {
+			t.Errorf("readdir %s want dir:%t, got:%t", e.Name(), isDir, e.IsDir())
+		}
+	}
+
+	for name, isDir := range expectDir {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if info.IsDir() != isDir {
+			t.Errorf("stat %s want dir:%t, got:%t", name, isDir, info.IsDir())
+		}
+	}
+
+	if _, err := fs.ReadFile(fsys, "a"); err == nil {
+		t.Errorf("readfile a want error reading a directory")
+	}
+	if b, err := fs.ReadFile(fsys, "a/b"); err != nil || string(b) != "b" {
+		t.Errorf("readfile a/b want:%q, got:%q %v", "b", b, err)
+	}
+	if b, err := fs.ReadFile(fsys, "x"); err != nil || string(b) != "x" {
+		t.Errorf("readfile x want:%q, got:%q %v", "x", b, err)
+	}
+}
@@ -1,6 +1,10 @@
 package fixity
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"fmt"
 	"hash"
 
@@ -10,6 +14,10 @@ import (
 
 const (
 	blake2b256 = "blake2b-256"
+	sha1Name   = "sha1"
+	sha2256    = "sha2-256"
+	sha2512    = "sha2-512"
+	md5Name    = "md5"
 
 	// DefaultMultihashName is the hasher function name from the multihash
 	// library that is being used for new fixity hashes.
@@ -46,10 +54,21 @@ func Hash(b []byte) (Ref, error) {
 
 // Hasher returns a *non-multihash* hash.Hash interface allowing incremental
 // writes to generate a sum.
+//
+// Along with the address hashes, widely recognized checksums such as
+// md5 and sha1 are supported for interop with existing catalogs.
 func Hasher(multihashName string) (hash.Hash, error) {
 	switch multihashName {
 	case blake2b256:
 		return blake2b.New256(), nil
+	case sha2256:
+		return sha256.New(), nil
+	case sha2512:
+		return sha512.New(), nil
+	case sha1Name:
+		return sha1.New(), nil
+	case md5Name:
+		return md5.New(), nil
 	default:
 		return nil, fmt.Errorf("unexpected multihash name: %q", multihashName)
 	}
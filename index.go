@@ -25,6 +25,12 @@ type Querier interface {
 type Match struct {
 	ID  string `json:"id"`
 	Ref Ref    `json:"ref"`
+
+	// Fields are the indexed fields of the match, if requested with
+	// q.Query.IncludeFields. Values are as stored by the index, and may
+	// not be the same type as the written value, such as numbers as
+	// float64.
+	Fields map[string]interface{} `json:"fields,omitempty"`
 }
 
 func NewIndexFromConfig(name string, c config.Config) (Index, error) {
@@ -1,9 +1,11 @@
 package bleve
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/blevesearch/bleve"
 	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
@@ -19,11 +21,32 @@ const (
 
 type Config struct {
 	Path string `json:"path"`
+
+	// IncludeFields, if not empty, limits indexing to only the listed
+	// value fields.
+	//
+	// Fields which are not indexed are still stored in the values blob,
+	// they are just not searchable.
+	IncludeFields []string `json:"includeFields,omitempty"`
+
+	// ExcludeFields are value fields which are never indexed, such as
+	// sensitive values which should not be searchable.
+	//
+	// Excluded fields take precedence over IncludeFields.
+	ExcludeFields []string `json:"excludeFields,omitempty"`
 }
 
 type Index struct {
+	rootPath string
+
+	// mu is held for reading by index and query calls, and for writing
+	// while the bleve indexes are closed, such as during a Snapshot.
+	mu       sync.RWMutex
 	idIndex  bleve.Index
 	refIndex bleve.Index
+
+	includeFields map[string]bool
+	excludeFields map[string]bool
 }
 
 func New(name string, cfg config.Config) (*Index, error) {
@@ -41,23 +64,82 @@ func New(name string, cfg config.Config) (*Index, error) {
 		return nil, fmt.Errorf("rootpath and bleve path empty")
 	}
 
-	idPath := filepath.Join(rootPath, idIndexDir)
-	refPath := filepath.Join(rootPath, refIndexDir)
+	ix := &Index{
+		rootPath:      rootPath,
+		includeFields: fieldSet(c.IncludeFields),
+		excludeFields: fieldSet(c.ExcludeFields),
+	}
 
-	idIndex, err := newBleve(idPath)
-	if err != nil {
-		return nil, fmt.Errorf("newBleve: %v", err)
+	if err := ix.open(); err != nil {
+		ix.close()
+		return nil, err // no wrap helper err
 	}
 
-	refIndex, err := newBleve(refPath)
-	if err != nil {
-		return nil, fmt.Errorf("newBleve: %v", err)
+	return ix, nil
+}
+
+// errClosed is returned while the bleve indexes are closed, such as
+// when a Snapshot or Restore failed to reopen them.
+var errClosed = errors.New("bleve index is closed")
+
+// open opens, or creates, the id and ref indexes within the rootPath,
+// skipping either which is already open.
+func (ix *Index) open() error {
+	if ix.idIndex == nil {
+		idIndex, err := newBleve(filepath.Join(ix.rootPath, idIndexDir))
+		if err != nil {
+			return fmt.Errorf("newBleve: %v", err)
+		}
+		ix.idIndex = idIndex
 	}
 
-	return &Index{
-		idIndex:  idIndex,
-		refIndex: refIndex,
-	}, nil
+	if ix.refIndex == nil {
+		refIndex, err := newBleve(filepath.Join(ix.rootPath, refIndexDir))
+		if err != nil {
+			return fmt.Errorf("newBleve: %v", err)
+		}
+		ix.refIndex = refIndex
+	}
+
+	return nil
+}
+
+// close closes the id and ref indexes, if open. Indexes which fail to
+// close are left open.
+func (ix *Index) close() error {
+	if ix.idIndex != nil {
+		if err := ix.idIndex.Close(); err != nil {
+			return fmt.Errorf("close id index: %v", err)
+		}
+		ix.idIndex = nil
+	}
+
+	if ix.refIndex != nil {
+		if err := ix.refIndex.Close(); err != nil {
+			return fmt.Errorf("close ref index: %v", err)
+		}
+		ix.refIndex = nil
+	}
+
+	return nil
+}
+
+// closed reports whether either index is closed, and must be checked
+// before using the indexes.
+func (ix *Index) closed() bool {
+	return ix.idIndex == nil || ix.refIndex == nil
+}
+
+func fieldSet(fields []string) map[string]bool {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		set[f] = true
+	}
+	return set
 }
 
 func newBleve(path string) (bleve.Index, error) {
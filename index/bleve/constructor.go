@@ -9,6 +9,7 @@ const configType = "bleve"
 
 func init() {
 	fixity.RegisterIndex(configType, fixity.IndexConstructorFunc(Constructor))
+	config.RegisterConfigType(configType, Config{})
 }
 
 func Constructor(n string, c config.Config) (fixity.Index, error) {
@@ -0,0 +1,54 @@
+package bleve
+
+import (
+	"fmt"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/index"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+)
+
+// Delete removes the mutation ref from the ref index, and from the id
+// index if it is the latest indexed version of its id.
+//
+// Deleting the latest version hides the whole id from queries without
+// versions, even if older versions remain. They are not promoted to
+// the id index, as the index does not record the order of versions,
+// but are still returned by queries with versions. The id is returned
+// by queries without versions again once a new version is indexed.
+func (ix *Index) Delete(mutRef fixity.Ref) error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.closed() {
+		return errClosed
+	}
+
+	matches, err := queryIndex(ix.refIndex, q.New().Eq(index.FRefKey, value.String(string(mutRef))).Limit(1))
+	if err != nil {
+		return fmt.Errorf("query ref index: %v", err)
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	id := matches[0].ID
+
+	if err := ix.refIndex.Delete(string(mutRef)); err != nil {
+		return fmt.Errorf("bleve ref index: %v", err)
+	}
+
+	latest, err := queryIndex(ix.idIndex, q.New().Const(q.Id(id)).Limit(1))
+	if err != nil {
+		return fmt.Errorf("query id index: %v", err)
+	}
+	if len(latest) == 0 || latest[0].Ref != mutRef {
+		return nil
+	}
+
+	if err := ix.idIndex.Delete(id); err != nil {
+		return fmt.Errorf("bleve id index: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package bleve
+
+import (
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+)
+
+func TestDelete(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	// ids and refs with non alpha-num characters, which the keyword
+	// analyzer must match whole.
+	for _, w := range []struct {
+		ID  string
+		Ref fixity.Ref
+	}{
+		{"foo-bar", "foo-1"},
+		{"foo-bar", "foo-2"},
+		{"baz/qux", "baz-1"},
+	} {
+		indexTest(t, ix, w.ID, w.Ref, nil)
+	}
+
+	testCases := []struct {
+		Delete     fixity.Ref
+		ID         string
+		IDMatches  int
+		RefMatches int
+	}{
+		// not indexed, a no-op.
+		{Delete: "missing", ID: "foo-bar", IDMatches: 1, RefMatches: 2},
+		// an older version leaves the latest id entry.
+		{Delete: "foo-1", ID: "foo-bar", IDMatches: 1, RefMatches: 1},
+		{Delete: "foo-2", ID: "foo-bar", IDMatches: 0, RefMatches: 0},
+		// deleting twice is a no-op, and other ids are unaffected.
+		{Delete: "foo-2", ID: "baz/qux", IDMatches: 1, RefMatches: 1},
+		{Delete: "baz-1", ID: "baz/qux", IDMatches: 0, RefMatches: 0},
+	}
+
+	for _, tc := range testCases {
+		if err := ix.Delete(tc.Delete); err != nil {
+			t.Fatalf("delete %s: %v", tc.Delete, err)
+		}
+
+		refs := matchRefs(t, ix, q.New().Const(q.Id(tc.ID)))
+		if len(refs) != tc.IDMatches {
+			t.Errorf("delete %s id matches want:%d, got:%v", tc.Delete, tc.IDMatches, refs)
+		}
+		if refs[tc.Delete] {
+			t.Errorf("delete %s still matched by id", tc.Delete)
+		}
+
+		refs = matchRefs(t, ix, q.New().Const(q.Id(tc.ID)).WithVersions())
+		if len(refs) != tc.RefMatches {
+			t.Errorf("delete %s version matches want:%d, got:%v", tc.Delete, tc.RefMatches, refs)
+		}
+		if refs[tc.Delete] {
+			t.Errorf("delete %s still matched by version", tc.Delete)
+		}
+	}
+}
+
+func TestDeleteLatestHidesID(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	indexTest(t, ix, "foo", "foo-1", nil)
+	indexTest(t, ix, "foo", "foo-2", nil)
+
+	if err := ix.Delete("foo-2"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// the older version is not promoted, hiding the id.
+	if refs := matchRefs(t, ix, q.New().Const(q.Id("foo"))); len(refs) != 0 {
+		t.Errorf("id matches want none, got:%v", refs)
+	}
+
+	refs := matchRefs(t, ix, q.New().Const(q.Id("foo")).WithVersions())
+	if len(refs) != 1 || !refs["foo-1"] {
+		t.Errorf("version matches want:[foo-1], got:%v", refs)
+	}
+
+	// a new version makes the id visible again.
+	indexTest(t, ix, "foo", "foo-3", nil)
+	refs = matchRefs(t, ix, q.New().Const(q.Id("foo")))
+	if len(refs) != 1 || !refs["foo-3"] {
+		t.Errorf("id matches want:[foo-3], got:%v", refs)
+	}
+}
@@ -9,19 +9,26 @@ import (
 )
 
 func (ix *Index) Index(ref fixity.Ref, m fixity.Mutation, d *fixity.DataSchema, v fixity.Values) error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.closed() {
+		return errClosed
+	}
 
 	indexedValues := map[string]interface{}{}
 
 	if v != nil {
 		for k, v := range v {
-			switch v.Type {
-			case value.TypeInt:
-				indexedValues[k] = v.IntValue
-			case value.TypeString:
-				indexedValues[k] = v.StringValue
-			default:
-				return fmt.Errorf("unhandled value type: %s", v.Type)
+			if !ix.indexesField(k) {
+				continue
+			}
+
+			iv, err := indexedValue(v)
+			if err != nil {
+				return fmt.Errorf("value %s: %v", k, err)
 			}
+			indexedValues[k] = iv
 		}
 	}
 
@@ -42,3 +49,47 @@ func (ix *Index) Index(ref fixity.Ref, m fixity.Mutation, d *fixity.DataSchema,
 
 	return nil
 }
+
+// indexesField reports whether the value field k should be indexed,
+// according to the configured include and exclude fields.
+func (ix *Index) indexesField(k string) bool {
+	if ix.excludeFields[k] {
+		return false
+	}
+
+	if ix.includeFields != nil && !ix.includeFields[k] {
+		return false
+	}
+
+	return true
+}
+
+// indexedValue returns the bleve friendly representation of the value.
+//
+// Lists are indexed as arrays, which bleve matches if any element of
+// the array matches.
+func indexedValue(v value.Value) (interface{}, error) {
+	switch v.Type {
+	case value.TypeInt:
+		return v.IntValue, nil
+	case value.TypeString:
+		return v.StringValue, nil
+	case value.TypeTime:
+		return v.TimeValue()
+	case value.TypeList:
+		l := make([]interface{}, len(v.ListValue))
+		for i, lv := range v.ListValue {
+			if lv.Type == value.TypeList {
+				return nil, fmt.Errorf("nested lists are not supported")
+			}
+			iv, err := indexedValue(lv)
+			if err != nil {
+				return nil, fmt.Errorf("list index %d: %v", i, err)
+			}
+			l[i] = iv
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("unhandled value type: %s", v.Type)
+	}
+}
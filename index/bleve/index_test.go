@@ -0,0 +1,120 @@
+package bleve
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+)
+
+// newTestIndex returns an index with the given raw config within a temp
+// dir, which is removed by the returned func.
+func newTestIndex(t *testing.T, rawConfig string) (*Index, func()) {
+	dir, err := ioutil.TempDir("", "fixity-bleve")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+
+	ix, err := New("test", config.Config{
+		RootPath: dir,
+		IndexConfigs: map[string]config.TypeConfig{
+			"test": {Type: configType, Config: []byte(rawConfig)},
+		},
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("new: %v", err)
+	}
+
+	return ix, func() {
+		ix.close()
+		os.RemoveAll(dir)
+	}
+}
+
+// indexTest indexes a mutation of id with the given ref and values.
+func indexTest(t *testing.T, ix *Index, id string, ref fixity.Ref, v fixity.Values) {
+	if err := ix.Index(ref, fixity.Mutation{ID: id}, nil, v); err != nil {
+		t.Fatalf("index %s: %v", ref, err)
+	}
+}
+
+// matchRefs returns the refs of the query matches, in any order.
+func matchRefs(t *testing.T, ix *Index, qu q.Query) map[fixity.Ref]bool {
+	matches, err := ix.Query(qu)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	refs := map[fixity.Ref]bool{}
+	for _, m := range matches {
+		refs[m.Ref] = true
+	}
+	return refs
+}
+
+func TestIndexesField(t *testing.T) {
+	testCases := []struct {
+		include, exclude []string
+		field            string
+		want             bool
+	}{
+		{field: "name", want: true},
+		{exclude: []string{"password"}, field: "password", want: false},
+		{exclude: []string{"password"}, field: "name", want: true},
+		{include: []string{"name"}, field: "name", want: true},
+		{include: []string{"name"}, field: "email", want: false},
+		{include: []string{"password"}, exclude: []string{"password"}, field: "password", want: false},
+	}
+
+	for _, tc := range testCases {
+		ix := &Index{
+			includeFields: fieldSet(tc.include),
+			excludeFields: fieldSet(tc.exclude),
+		}
+		if got := ix.indexesField(tc.field); got != tc.want {
+			t.Errorf("include:%v exclude:%v field %q want:%t, got:%t",
+				tc.include, tc.exclude, tc.field, tc.want, got)
+		}
+	}
+}
+
+func TestIndexList(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	indexTest(t, ix, "foo", "fooref", fixity.Values{
+		"tag": value.List(value.String("red"), value.String("green"), value.String("blue")),
+	})
+	indexTest(t, ix, "bar", "barref", fixity.Values{
+		"tag": value.String("yellow"),
+	})
+
+	testCases := []struct {
+		Tag    string
+		Expect []fixity.Ref
+	}{
+		{"red", []fixity.Ref{"fooref"}},
+		{"green", []fixity.Ref{"fooref"}},
+		{"blue", []fixity.Ref{"fooref"}},
+		{"yellow", []fixity.Ref{"barref"}},
+		{"purple", nil},
+	}
+
+	for _, tc := range testCases {
+		refs := matchRefs(t, ix, q.New().Eq("tag", value.String(tc.Tag)))
+		if len(refs) != len(tc.Expect) {
+			t.Errorf("tag %s matches want:%v, got:%v", tc.Tag, tc.Expect, refs)
+			continue
+		}
+		for _, ref := range tc.Expect {
+			if !refs[ref] {
+				t.Errorf("tag %s want match:%s, got:%v", tc.Tag, ref, refs)
+			}
+		}
+	}
+}
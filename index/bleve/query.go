@@ -1,7 +1,9 @@
 package bleve
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/blevesearch/bleve"
 	"github.com/blevesearch/bleve/search/query"
@@ -9,6 +11,7 @@ import (
 	"github.com/leeola/fixity/index"
 	"github.com/leeola/fixity/q"
 	"github.com/leeola/fixity/q/operator"
+	"github.com/leeola/fixity/value"
 )
 
 const (
@@ -17,6 +20,13 @@ const (
 )
 
 func (ix *Index) Query(qu q.Query) ([]fixity.Match, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.closed() {
+		return nil, errClosed
+	}
+
 	var index bleve.Index
 	if qu.IncludeVersions {
 		index = ix.refIndex
@@ -27,20 +37,105 @@ func (ix *Index) Query(qu q.Query) ([]fixity.Match, error) {
 	return queryIndex(index, qu)
 }
 
+// Explain runs the query, returning the translated bleve query along
+// with the score of each match.
+func (ix *Index) Explain(qu q.Query) (index.Explanation, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.closed() {
+		return index.Explanation{}, errClosed
+	}
+
+	var bix bleve.Index
+	if qu.IncludeVersions {
+		bix = ix.refIndex
+	} else {
+		bix = ix.idIndex
+	}
+
+	bq, err := fixQtoBleveQ(qu.Constraint)
+	if err != nil {
+		return index.Explanation{}, err // avoiding helper context to callers
+	}
+
+	backend, err := explainBleveQ(bq)
+	if err != nil {
+		return index.Explanation{}, err
+	}
+
+	search := searchRequest(bq, qu)
+	search.Explain = true
+
+	searchResults, err := bix.Search(search)
+	if err != nil {
+		return index.Explanation{}, fmt.Errorf("search: %v", err)
+	}
+
+	matches, err := hitsToMatches(searchResults, qu.IncludeFields)
+	if err != nil {
+		return index.Explanation{}, err
+	}
+
+	hits := make([]index.ExplainedMatch, len(matches))
+	for i, m := range matches {
+		hit := searchResults.Hits[i]
+		hits[i] = index.ExplainedMatch{
+			Match: m,
+			Score: hit.Score,
+		}
+		if hit.Expl != nil {
+			hits[i].Detail = hit.Expl.String()
+		}
+	}
+
+	return index.Explanation{
+		Backend: backend,
+		Hits:    hits,
+	}, nil
+}
+
+// explainBleveQ returns the json representation of the bleve query.
+func explainBleveQ(bq query.Query) (string, error) {
+	b, err := json.MarshalIndent(bq, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal bleve query: %v", err)
+	}
+	return string(b), nil
+}
+
 func queryIndex(ix bleve.Index, qu q.Query) ([]fixity.Match, error) {
 	bq, err := fixQtoBleveQ(qu.Constraint)
 	if err != nil {
 		return nil, err // avoiding helper context to callers
 	}
 
-	search := bleve.NewSearchRequest(bq)
-	search.Fields = []string{fieldNameID, fieldNameRef}
-
-	searchResults, err := ix.Search(search)
+	searchResults, err := ix.Search(searchRequest(bq, qu))
 	if err != nil {
 		return nil, fmt.Errorf("search: %v", err)
 	}
 
+	return hitsToMatches(searchResults, qu.IncludeFields)
+}
+
+func searchRequest(bq query.Query, qu q.Query) *bleve.SearchRequest {
+	search := bleve.NewSearchRequest(bq)
+	if qu.IncludeFields {
+		// all stored fields, which includes the id and ref.
+		search.Fields = []string{"*"}
+	} else {
+		search.Fields = []string{fieldNameID, fieldNameRef}
+	}
+	if qu.LimitBy > 0 {
+		search.Size = qu.LimitBy
+	}
+	if qu.SkipBy > 0 {
+		search.From = qu.SkipBy
+	}
+	return search
+}
+
+func hitsToMatches(searchResults *bleve.SearchResult, includeFields bool) ([]fixity.Match, error) {
 	matches := make([]fixity.Match, len(searchResults.Hits))
 
 	for i, hit := range searchResults.Hits {
@@ -68,11 +163,28 @@ func queryIndex(ix bleve.Index, qu q.Query) ([]fixity.Match, error) {
 			ID:  id,
 			Ref: fixity.Ref(refStr),
 		}
+
+		if includeFields {
+			matches[i].Fields = matchFields(hit.Fields)
+		}
 	}
 
 	return matches, nil
 }
 
+// matchFields returns the stored fields of a hit, excluding the id and
+// ref which are already part of the match.
+func matchFields(hitFields map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(hitFields))
+	for k, v := range hitFields {
+		if k == fieldNameID || k == fieldNameRef {
+			continue
+		}
+		fields[k] = v
+	}
+	return fields
+}
+
 func fixQtoBleveQ(c q.Constraint) (query.Query, error) {
 	switch c.Operator {
 	case operator.Equal:
@@ -89,7 +201,114 @@ func fixQtoBleveQ(c q.Constraint) (query.Query, error) {
 			bq.FieldVal = *c.Field
 		}
 		return bq, nil
+	case operator.And, operator.Or:
+		if len(c.SubConstraints) == 0 {
+			return nil, fmt.Errorf("%s op missing subconstraints", c.Operator)
+		}
+		bqs := make([]query.Query, len(c.SubConstraints))
+		for i, sc := range c.SubConstraints {
+			bq, err := fixQtoBleveQ(sc)
+			if err != nil {
+				return nil, err
+			}
+			bqs[i] = bq
+		}
+		if c.Operator == operator.Or {
+			return bleve.NewDisjunctionQuery(bqs...), nil
+		}
+		return bleve.NewConjunctionQuery(bqs...), nil
+	case operator.Prefix:
+		if c.Field == nil || c.Value == nil {
+			return nil, fmt.Errorf("field or value nil on prefix op")
+		}
+		s, err := c.Value.ToString()
+		if err != nil {
+			return nil, fmt.Errorf("prefix tostring: %v", err)
+		}
+		bq := bleve.NewPrefixQuery(s)
+		bq.SetField(*c.Field)
+		return bq, nil
+	case operator.GreaterThan, operator.GreaterThanEqual,
+		operator.LessThan, operator.LessThanEqual:
+		return rangeQuery(c)
 	default:
 		return nil, fmt.Errorf("unsupported constraint operator: %q", c.Operator)
 	}
 }
+
+// rangeQuery converts a comparison constraint into a bleve range query,
+// leaving the opposing end of the range unbounded.
+func rangeQuery(c q.Constraint) (query.Query, error) {
+	if c.Field == nil || c.Value == nil {
+		return nil, fmt.Errorf("field or value nil on %s op", c.Operator)
+	}
+
+	var (
+		inclusive = c.Operator == operator.GreaterThanEqual ||
+			c.Operator == operator.LessThanEqual
+		lower = c.Operator == operator.GreaterThan ||
+			c.Operator == operator.GreaterThanEqual
+	)
+
+	switch c.Value.Type {
+	case value.TypeTime:
+		t, err := c.Value.TimeValue()
+		if err != nil {
+			return nil, fmt.Errorf("range timevalue: %v", err)
+		}
+
+		// zero times are treated as unbounded by bleve.
+		var start, end time.Time
+		if lower {
+			start = t
+		} else {
+			end = t
+		}
+
+		bq := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		bq.SetField(*c.Field)
+		return bq, nil
+	case value.TypeInt:
+		f := float64(c.Value.IntValue)
+
+		var min, max *float64
+		if lower {
+			min = &f
+		} else {
+			max = &f
+		}
+
+		bq := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		bq.SetField(*c.Field)
+		return bq, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s value type: %s", c.Operator, c.Value.Type)
+	}
+}
+
+// Warm runs a trivial id query against both bleve indexes, forcing the
+// lazy initialization of mappings and analyzers which would otherwise
+// slow the first real query.
+func (ix *Index) Warm() error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if ix.closed() {
+		return errClosed
+	}
+
+	bq, err := fixQtoBleveQ(q.Id(""))
+	if err != nil {
+		return err // avoiding helper context to callers
+	}
+
+	search := searchRequest(bq, q.New().Limit(1))
+	if _, err := ix.idIndex.Search(search); err != nil {
+		return fmt.Errorf("search id index: %v", err)
+	}
+	if _, err := ix.refIndex.Search(search); err != nil {
+		return fmt.Errorf("search ref index: %v", err)
+	}
+
+	return nil
+}
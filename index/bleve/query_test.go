@@ -0,0 +1,242 @@
+package bleve
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+)
+
+func TestFixQtoBleveQNested(t *testing.T) {
+	a := q.Eq("a", value.String("1"))
+	b := q.Eq("b", value.String("2"))
+	c := q.Eq("c", value.String("3"))
+
+	bq, err := fixQtoBleveQ(a.And(b).Or(c))
+	if err != nil {
+		t.Fatalf("fixqtobleveq: %v", err)
+	}
+
+	or, ok := bq.(*query.DisjunctionQuery)
+	if !ok {
+		t.Fatalf("root want DisjunctionQuery, got:%T", bq)
+	}
+	if len(or.Disjuncts) != 2 {
+		t.Fatalf("disjuncts want:2, got:%d", len(or.Disjuncts))
+	}
+
+	and, ok := or.Disjuncts[0].(*query.ConjunctionQuery)
+	if !ok {
+		t.Fatalf("first disjunct want ConjunctionQuery, got:%T", or.Disjuncts[0])
+	}
+	if len(and.Conjuncts) != 2 {
+		t.Errorf("conjuncts want:2, got:%d", len(and.Conjuncts))
+	}
+
+	term, ok := or.Disjuncts[1].(*query.TermQuery)
+	if !ok {
+		t.Fatalf("second disjunct want TermQuery, got:%T", or.Disjuncts[1])
+	}
+	if term.Term != "3" || term.FieldVal != "c" {
+		t.Errorf("term want c:3, got:%s:%s", term.FieldVal, term.Term)
+	}
+}
+
+func TestExplainBleveQ(t *testing.T) {
+	bq, err := fixQtoBleveQ(q.Eq("color", value.String("blue")).Or(q.Prefix("name", "fo")))
+	if err != nil {
+		t.Fatalf("fixqtobleveq: %v", err)
+	}
+
+	explanation, err := explainBleveQ(bq)
+	if err != nil {
+		t.Fatalf("explainbleveq: %v", err)
+	}
+
+	for _, expect := range []string{`"color"`, `"blue"`, `"name"`, `"fo"`} {
+		if !strings.Contains(explanation, expect) {
+			t.Errorf("explanation missing %s: %s", expect, explanation)
+		}
+	}
+}
+
+func TestSearchRequestPaging(t *testing.T) {
+	testCases := []struct {
+		Query      q.Query
+		Size, From int
+	}{
+		{Query: q.New(), Size: 10, From: 0},
+		{Query: q.New().Limit(5), Size: 5, From: 0},
+		{Query: q.New().Skip(20).Limit(10), Size: 10, From: 20},
+	}
+
+	for _, tc := range testCases {
+		search := searchRequest(bleve.NewMatchAllQuery(), tc.Query)
+		if search.Size != tc.Size || search.From != tc.From {
+			t.Errorf("skip:%d limit:%d want size:%d from:%d, got size:%d from:%d",
+				tc.Query.SkipBy, tc.Query.LimitBy, tc.Size, tc.From, search.Size, search.From)
+		}
+	}
+}
+
+func TestHitsToMatchesFields(t *testing.T) {
+	results := &bleve.SearchResult{
+		Hits: search.DocumentMatchCollection{
+			{Fields: map[string]interface{}{
+				fieldNameID:  "foo",
+				fieldNameRef: "fooref",
+				"color":      "blue",
+				"count":      float64(3),
+			}},
+		},
+	}
+
+	testCases := []struct {
+		IncludeFields bool
+		Expect        map[string]interface{}
+	}{
+		{IncludeFields: false, Expect: nil},
+		{IncludeFields: true, Expect: map[string]interface{}{
+			"color": "blue",
+			"count": float64(3),
+		}},
+	}
+
+	for _, tc := range testCases {
+		if tc.IncludeFields {
+			req := searchRequest(bleve.NewMatchAllQuery(), q.New().WithFields())
+			if len(req.Fields) != 1 || req.Fields[0] != "*" {
+				t.Errorf("search fields want all, got:%v", req.Fields)
+			}
+		}
+
+		matches, err := hitsToMatches(results, tc.IncludeFields)
+		if err != nil {
+			t.Fatalf("hitstomatches: %v", err)
+		}
+		if len(matches) != 1 {
+			t.Fatalf("matches want:1, got:%d", len(matches))
+		}
+
+		m := matches[0]
+		if m.ID != "foo" || m.Ref != "fooref" {
+			t.Errorf("match want foo:fooref, got:%s:%s", m.ID, m.Ref)
+		}
+		if !reflect.DeepEqual(m.Fields, tc.Expect) {
+			t.Errorf("includeFields:%t want fields:%v, got:%v", tc.IncludeFields, tc.Expect, m.Fields)
+		}
+	}
+}
+
+func TestFixQtoBleveQIdIn(t *testing.T) {
+	bq, err := fixQtoBleveQ(q.IdIn("a", "b"))
+	if err != nil {
+		t.Fatalf("fixqtobleveq: %v", err)
+	}
+
+	or, ok := bq.(*query.DisjunctionQuery)
+	if !ok {
+		t.Fatalf("want DisjunctionQuery, got:%T", bq)
+	}
+
+	var ids []string
+	for _, d := range or.Disjuncts {
+		term, ok := d.(*query.TermQuery)
+		if !ok {
+			t.Fatalf("disjunct want TermQuery, got:%T", d)
+		}
+		if term.FieldVal != fieldNameID {
+			t.Errorf("term field want:%s, got:%s", fieldNameID, term.FieldVal)
+		}
+		ids = append(ids, term.Term)
+	}
+
+	if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("ids want:[a b], got:%v", ids)
+	}
+}
+
+func TestQueryIdPrefix(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	for _, id := range []string{"a/b", "a/c", "d/e"} {
+		indexTest(t, ix, id, fixity.Ref(id+"-ref"), nil)
+	}
+
+	testCases := []struct {
+		Prefix string
+		Expect []fixity.Ref
+	}{
+		{"a/", []fixity.Ref{"a/b-ref", "a/c-ref"}},
+		{"a/b", []fixity.Ref{"a/b-ref"}},
+		{"d/", []fixity.Ref{"d/e-ref"}},
+		{"e/", nil},
+	}
+
+	for _, tc := range testCases {
+		refs := matchRefs(t, ix, q.New().Const(q.IdPrefix(tc.Prefix)))
+		if len(refs) != len(tc.Expect) {
+			t.Errorf("prefix %q matches want:%v, got:%v", tc.Prefix, tc.Expect, refs)
+			continue
+		}
+		for _, ref := range tc.Expect {
+			if !refs[ref] {
+				t.Errorf("prefix %q want match:%s, got:%v", tc.Prefix, ref, refs)
+			}
+		}
+	}
+}
+
+func TestQueryIdIn(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	// two versions of each id, only the latest is in the id index.
+	for _, version := range []string{"1", "2"} {
+		for _, id := range []string{"a", "b", "c", "d"} {
+			indexTest(t, ix, id, fixity.Ref(id+version), nil)
+		}
+	}
+
+	matches, err := ix.Query(q.New().Const(q.IdIn("a", "c", "missing")))
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	got := map[string]fixity.Ref{}
+	for _, m := range matches {
+		got[m.ID] = m.Ref
+	}
+
+	expect := map[string]fixity.Ref{"a": "a2", "c": "c2"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("matches want:%v, got:%v", expect, got)
+	}
+}
+
+func TestWarm(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	// a fresh index has no documents to match, which is not an error.
+	if err := ix.Warm(); err != nil {
+		t.Fatalf("warm: %v", err)
+	}
+
+	indexTest(t, ix, "foo", "fooref", nil)
+	if err := ix.Warm(); err != nil {
+		t.Errorf("warm after index: %v", err)
+	}
+
+	ix.close()
+	if err := ix.Warm(); err != errClosed {
+		t.Errorf("warm closed want:%v, got:%v", errClosed, err)
+	}
+}
@@ -0,0 +1,178 @@
+package bleve
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Snapshot copies the index to destPath, which can later be loaded with
+// Restore rather than reindexing the store.
+//
+// The bleve indexes are closed while copying so that the copy is
+// consistent, blocking index and query calls until the copy is done.
+func (ix *Index) Snapshot(destPath string) error {
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("snapshot path exists: %s", destPath)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("stat: %v", err)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if err := ix.close(); err != nil {
+		return ix.reopenAfter(err)
+	}
+
+	copyErr := copyDir(ix.rootPath, destPath)
+
+	// reopen even if the copy failed, so the index remains usable.
+	if err := ix.open(); err != nil {
+		return fmt.Errorf("reopen: %v", err)
+	}
+
+	if copyErr != nil {
+		os.RemoveAll(destPath)
+		return fmt.Errorf("copy: %v", copyErr)
+	}
+
+	return nil
+}
+
+// Restore replaces the index with a snapshot previously written by
+// Snapshot.
+//
+// The snapshot is copied alongside the index before replacing it, and
+// the current index is moved aside rather than removed until the
+// snapshot is opened, so a failed restore leaves the current index
+// intact.
+func (ix *Index) Restore(srcPath string) error {
+	for _, dir := range []string{idIndexDir, refIndexDir} {
+		fi, err := os.Stat(filepath.Join(srcPath, dir))
+		if err != nil {
+			return fmt.Errorf("invalid snapshot: %v", err)
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("invalid snapshot: %s is not a directory", dir)
+		}
+	}
+
+	tmpPath := ix.rootPath + ".restore"
+	oldPath := ix.rootPath + ".old"
+	for _, p := range []string{tmpPath, oldPath} {
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("removeall %s: %v", p, err)
+		}
+	}
+
+	if err := copyDir(srcPath, tmpPath); err != nil {
+		os.RemoveAll(tmpPath)
+		return fmt.Errorf("copy: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if err := ix.close(); err != nil {
+		return ix.reopenAfter(err)
+	}
+
+	if err := os.Rename(ix.rootPath, oldPath); err != nil {
+		return ix.reopenAfter(fmt.Errorf("rename current: %v", err))
+	}
+
+	if err := os.Rename(tmpPath, ix.rootPath); err != nil {
+		return ix.rollback(oldPath, fmt.Errorf("rename snapshot: %v", err))
+	}
+
+	if err := ix.open(); err != nil {
+		return ix.rollback(oldPath, fmt.Errorf("open snapshot: %v", err))
+	}
+
+	// the restore succeeded, failing to remove the old index only
+	// leaves it on disk.
+	os.RemoveAll(oldPath)
+
+	return nil
+}
+
+// reopenAfter reopens any closed index after the failure err, so that
+// the index remains usable, returning err.
+func (ix *Index) reopenAfter(err error) error {
+	if openErr := ix.open(); openErr != nil {
+		return fmt.Errorf("%v, and reopen: %v", err, openErr)
+	}
+	return err
+}
+
+// rollback moves the index at oldPath back to the rootPath and reopens
+// it, after a Restore failed with err.
+func (ix *Index) rollback(oldPath string, err error) error {
+	if closeErr := ix.close(); closeErr != nil {
+		return fmt.Errorf("%v, and close: %v", err, closeErr)
+	}
+
+	if rmErr := os.RemoveAll(ix.rootPath); rmErr != nil {
+		return fmt.Errorf("%v, and removeall: %v", err, rmErr)
+	}
+
+	if renameErr := os.Rename(oldPath, ix.rootPath); renameErr != nil {
+		return fmt.Errorf("%v, and rename old index: %v", err, renameErr)
+	}
+
+	return ix.reopenAfter(err)
+}
+
+// copyDir recursively copies the regular files and directories of src
+// to dst, which must not exist.
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case fi.IsDir():
+			return os.MkdirAll(target, fi.Mode().Perm())
+		case fi.Mode().IsRegular():
+			return copyFile(path, target, fi.Mode().Perm())
+		default:
+			return errors.New("unsupported file type: " + path)
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
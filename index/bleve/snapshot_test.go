@@ -0,0 +1,104 @@
+package bleve
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	indexTest(t, ix, "foo", "foo1", fixity.Values{"color": value.String("red")})
+	indexTest(t, ix, "bar", "bar1", fixity.Values{"color": value.String("red")})
+	indexTest(t, ix, "baz", "baz1", fixity.Values{"color": value.String("blue")})
+
+	red := q.New().Eq("color", value.String("red"))
+	versions := q.New().Const(q.IdIn("foo", "bar", "baz")).WithVersions()
+
+	wantRed := matchRefs(t, ix, red)
+	wantVersions := matchRefs(t, ix, versions)
+	if len(wantRed) != 2 || len(wantVersions) != 3 {
+		t.Fatalf("indexed matches want 2 red and 3 versions, got:%v and %v", wantRed, wantVersions)
+	}
+
+	snapshotPath := filepath.Join(filepath.Dir(ix.rootPath), "snapshot")
+	if err := ix.Snapshot(snapshotPath); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	if err := ix.Snapshot(snapshotPath); err == nil {
+		t.Errorf("snapshot to existing path want error")
+	}
+
+	// the index remains usable after snapshotting, and diverges from
+	// the snapshot.
+	indexTest(t, ix, "foo", "foo2", fixity.Values{"color": value.String("blue")})
+	if err := ix.Delete("bar1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := matchRefs(t, ix, red); len(got) != 0 {
+		t.Fatalf("diverged red matches want none, got:%v", got)
+	}
+
+	if err := ix.Restore(filepath.Join(filepath.Dir(ix.rootPath), "missing")); err == nil {
+		t.Errorf("restore from missing snapshot want error")
+	}
+	if got := matchRefs(t, ix, red); len(got) != 0 {
+		t.Errorf("failed restore want index unchanged, got red matches:%v", got)
+	}
+
+	if err := ix.Restore(snapshotPath); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if got := matchRefs(t, ix, red); !reflect.DeepEqual(got, wantRed) {
+		t.Errorf("restored red matches want:%v, got:%v", wantRed, got)
+	}
+	if got := matchRefs(t, ix, versions); !reflect.DeepEqual(got, wantVersions) {
+		t.Errorf("restored versions want:%v, got:%v", wantVersions, got)
+	}
+
+	for _, p := range []string{ix.rootPath + ".restore", ix.rootPath + ".old"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("restore want %s removed, got:%v", p, err)
+		}
+	}
+}
+
+func TestRestoreRollback(t *testing.T) {
+	ix, cleanup := newTestIndex(t, `{"path":"index"}`)
+	defer cleanup()
+
+	indexTest(t, ix, "foo", "foo1", nil)
+
+	// a snapshot which passes validation, but cannot be opened.
+	snapshotPath := filepath.Join(filepath.Dir(ix.rootPath), "snapshot")
+	for _, dir := range []string{idIndexDir, refIndexDir} {
+		p := filepath.Join(snapshotPath, dir)
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatalf("mkdirall: %v", err)
+		}
+		f, err := os.Create(filepath.Join(p, "index_meta.json"))
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		f.WriteString("not json")
+		f.Close()
+	}
+
+	if err := ix.Restore(snapshotPath); err == nil {
+		t.Fatalf("restore of corrupt snapshot want error")
+	}
+
+	got := matchRefs(t, ix, q.New().Const(q.Id("foo")))
+	if !got["foo1"] {
+		t.Errorf("rolled back index want foo1, got:%v", got)
+	}
+}
@@ -0,0 +1,36 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+)
+
+// Fallback is a Querier trying each of its Queriers in order, returning
+// the results of the first which does not error.
+//
+// Any error falls through to the next Querier, as an unavailable or
+// out of date index cannot be distinguished from other failures by
+// every backend. Empty results are not errors, and are returned
+// without trying the remaining Queriers.
+type Fallback []Querier
+
+func (f Fallback) Query(qu q.Query) ([]fixity.Match, error) {
+	if len(f) == 0 {
+		return nil, errors.New("fallback has no queriers")
+	}
+
+	errs := make([]string, 0, len(f))
+	for i, querier := range f {
+		matches, err := querier.Query(qu)
+		if err == nil {
+			return matches, nil
+		}
+		errs = append(errs, fmt.Sprintf("querier %d: %v", i, err))
+	}
+
+	return nil, fmt.Errorf("all queriers failed: %s", strings.Join(errs, "; "))
+}
@@ -0,0 +1,74 @@
+package index
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/q"
+)
+
+type stubQuerier struct {
+	matches []fixity.Match
+	err     error
+	calls   int
+}
+
+func (s *stubQuerier) Query(q.Query) ([]fixity.Match, error) {
+	s.calls++
+	return s.matches, s.err
+}
+
+func TestFallback(t *testing.T) {
+	match := fixity.Match{ID: "foo", Ref: "fooref"}
+
+	testCases := []struct {
+		Name               string
+		Primary, Secondary *stubQuerier
+		Matches            int
+		Err                bool
+		SecondaryCalls     int
+	}{
+		{
+			Name:           "primary succeeds",
+			Primary:        &stubQuerier{matches: []fixity.Match{match}},
+			Secondary:      &stubQuerier{},
+			Matches:        1,
+			SecondaryCalls: 0,
+		},
+		{
+			Name:           "primary no results",
+			Primary:        &stubQuerier{},
+			Secondary:      &stubQuerier{matches: []fixity.Match{match}},
+			Matches:        0,
+			SecondaryCalls: 0,
+		},
+		{
+			Name:           "primary errors",
+			Primary:        &stubQuerier{err: errors.New("unavailable")},
+			Secondary:      &stubQuerier{matches: []fixity.Match{match}},
+			Matches:        1,
+			SecondaryCalls: 1,
+		},
+		{
+			Name:           "all error",
+			Primary:        &stubQuerier{err: errors.New("unavailable")},
+			Secondary:      &stubQuerier{err: errors.New("unavailable")},
+			Err:            true,
+			SecondaryCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		matches, err := Fallback{tc.Primary, tc.Secondary}.Query(q.New())
+		if tc.Err != (err != nil) {
+			t.Errorf("%s want err:%t, got:%v", tc.Name, tc.Err, err)
+		}
+		if len(matches) != tc.Matches {
+			t.Errorf("%s matches want:%d, got:%d", tc.Name, tc.Matches, len(matches))
+		}
+		if tc.Secondary.calls != tc.SecondaryCalls {
+			t.Errorf("%s secondary calls want:%d, got:%d", tc.Name, tc.SecondaryCalls, tc.Secondary.calls)
+		}
+	}
+}
@@ -20,6 +20,45 @@ type Querier interface {
 	Query(q.Query) ([]fixity.Match, error)
 }
 
+// Explainer is implemented by indexes able to describe how a query is
+// executed, to debug queries returning unexpected results.
+type Explainer interface {
+	Explain(q.Query) (Explanation, error)
+}
+
+// Warmer is implemented by indexes which lazily initialize on their
+// first query, allowing the initialization to happen before the first
+// real query, such as when the store is constructed.
+type Warmer interface {
+	Warm() error
+}
+
+// Deleter is implemented by indexes able to remove the entries of a
+// mutation ref, such that it is no longer returned by queries.
+//
+// Deleting a ref which is not indexed is not an error.
+type Deleter interface {
+	Delete(mutRef fixity.Ref) error
+}
+
+// Explanation describes how an index executed a query.
+type Explanation struct {
+	// Backend is the query as translated for the index backend.
+	Backend string `json:"backend"`
+
+	Hits []ExplainedMatch `json:"hits"`
+}
+
+// ExplainedMatch is a query match along with its backend score.
+type ExplainedMatch struct {
+	fixity.Match
+
+	Score float64 `json:"score"`
+
+	// Detail is the backend's explanation of the score, if any.
+	Detail string `json:"detail,omitempty"`
+}
+
 const (
 	FIDKey       string = "fid"
 	FRefKey      string = "fref"
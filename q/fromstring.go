@@ -1,7 +1,9 @@
 package q
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/leeola/fixity/q/operator"
 	"github.com/leeola/fixity/value"
@@ -12,10 +14,20 @@ import (
 //
 // Intended for constructing Queries from user input.
 //
+// Field values may be prefixed with a comparison, such as
+// "size:>=1024". Values which parse as a time expression, such as
+// "created:>2023-01-01" or "modified:last-7-days", are converted to
+// time range constraints. See value.ParseTimeRange for the supported
+// time forms.
+//
 // TODO(leeola): support AND/OR by looking check if one of the parts equals
 // AND/OR directly. Can also support -AND and -OR. Though i may have to
 // implement my own parsing, to group ( and ), eg AND( ... ).
 func FromString(s string) Query {
+	return fromString(s, time.Now())
+}
+
+func fromString(s string, now time.Time) Query {
 	parts := str.ToArgv(s)
 
 	// the fieldless constraint is any parts that do not produce
@@ -31,10 +43,29 @@ func FromString(s string) Query {
 			continue
 		}
 
+		if op == "" {
+			op, valueStr = splitComparison(valueStr)
+		}
+
 		switch op {
 		case "eq":
 			op = operator.Equal
 
+		case "gt":
+			op = operator.GreaterThan
+
+		case "gte":
+			op = operator.GreaterThanEqual
+
+		case "lt":
+			op = operator.LessThan
+
+		case "lte":
+			op = operator.LessThanEqual
+
+		case "prefix":
+			op = operator.Prefix
+
 		case "":
 			// default empty ops to equal.
 			//
@@ -43,7 +74,20 @@ func FromString(s string) Query {
 			op = operator.Equal
 		}
 
+		if start, end, err := value.ParseTimeRange(valueStr, now); err == nil {
+			if tcs := timeConstraints(op, field, start, end); tcs != nil {
+				cs = append(cs, tcs...)
+				continue
+			}
+		}
+
 		v := value.String(valueStr)
+		if op != operator.Equal {
+			// comparisons are only meaningful against numbers.
+			if i, err := strconv.Atoi(valueStr); err == nil {
+				v = value.Int(i)
+			}
+		}
 
 		cs = append(cs, Constraint{
 			Operator: op,
@@ -67,21 +111,72 @@ func FromString(s string) Query {
 	return New().And(cs...)
 }
 
+// timeConstraints converts the [start, end) range of a time expression
+// into the constraints matching the given operator.
+//
+// Nil is returned if the operator does not support time ranges.
+func timeConstraints(op, field string, start, end time.Time) []Constraint {
+	switch op {
+	case operator.Equal:
+		return []Constraint{
+			Gte(field, value.Time(start)),
+			Lt(field, value.Time(end)),
+		}
+	case operator.GreaterThan:
+		return []Constraint{Gte(field, value.Time(end))}
+	case operator.GreaterThanEqual:
+		return []Constraint{Gte(field, value.Time(start))}
+	case operator.LessThan:
+		return []Constraint{Lt(field, value.Time(start))}
+	case operator.LessThanEqual:
+		return []Constraint{Lt(field, value.Time(end))}
+	default:
+		return nil
+	}
+}
+
 func splitPart(s string) (op, field, value string) {
 	constStrs := strings.SplitN(s, ":", 3)
-	switch len(constStrs) {
-	case 1:
+	switch {
+	case len(constStrs) == 1:
 		// "value"
 		value = constStrs[0]
-	case 2:
-		// "field:value"
-		field = constStrs[0]
-		value = constStrs[1]
-	default:
+	case len(constStrs) == 3 && isOpName(constStrs[0]):
 		// "op:field:value"
 		op = constStrs[0]
 		field = constStrs[1]
 		value = constStrs[2]
+	default:
+		// "field:value", where value may contain colons, such as
+		// an RFC3339 time.
+		field = constStrs[0]
+		value = strings.Join(constStrs[1:], ":")
 	}
 	return
 }
+
+func isOpName(s string) bool {
+	switch s {
+	case "eq", "gt", "gte", "lt", "lte", "prefix":
+		return true
+	default:
+		return false
+	}
+}
+
+// splitComparison splits a leading comparison, such as ">=", from the
+// value, returning the equivalent op name.
+func splitComparison(s string) (op, value string) {
+	switch {
+	case strings.HasPrefix(s, ">="):
+		return "gte", s[2:]
+	case strings.HasPrefix(s, "<="):
+		return "lte", s[2:]
+	case strings.HasPrefix(s, ">"):
+		return "gt", s[1:]
+	case strings.HasPrefix(s, "<"):
+		return "lt", s[1:]
+	default:
+		return "", s
+	}
+}
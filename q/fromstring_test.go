@@ -0,0 +1,80 @@
+package q
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity/q/operator"
+	"github.com/leeola/fixity/value"
+)
+
+func TestFromStringTime(t *testing.T) {
+	now := time.Date(2023, 3, 15, 13, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Input  string
+		Expect []Constraint
+	}{
+		{
+			Input: "created:>2023-01-01",
+			Expect: []Constraint{
+				Gte("created", value.Time(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))),
+			},
+		},
+		{
+			Input: "created:<=2023-01-01",
+			Expect: []Constraint{
+				Lt("created", value.Time(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))),
+			},
+		},
+		{
+			Input: "modified:last-7-days",
+			Expect: []Constraint{
+				Gte("modified", value.Time(time.Date(2023, 3, 8, 13, 30, 0, 0, time.UTC))),
+				Lt("modified", value.Time(now)),
+			},
+		},
+		{
+			Input: "created:2023-01-01T10:00:00Z",
+			Expect: []Constraint{
+				Gte("created", value.Time(time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC))),
+				Lt("created", value.Time(time.Date(2023, 1, 1, 10, 0, 1, 0, time.UTC))),
+			},
+		},
+		{
+			Input: "size:>=1024",
+			Expect: []Constraint{
+				Gte("size", value.Int(1024)),
+			},
+		},
+		{
+			Input: "name:foo",
+			Expect: []Constraint{
+				Eq("name", value.String("foo")),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		c := fromString(testCase.Input, now).Constraint
+
+		var got []Constraint
+		if c.Operator == operator.And {
+			got = c.SubConstraints
+		} else {
+			got = []Constraint{c}
+		}
+
+		if len(got) != len(testCase.Expect) {
+			t.Errorf("%s want:%d constraints, got:%d", testCase.Input, len(testCase.Expect), len(got))
+			continue
+		}
+		for i, g := range got {
+			e := testCase.Expect[i]
+			if g.Operator != e.Operator || *g.Field != *e.Field || !reflect.DeepEqual(*g.Value, *e.Value) {
+				t.Errorf("%s constraint %d want:%s %s %s, got:%s %s %s", testCase.Input, i,
+					*e.Field, e.Operator, e.Value, *g.Field, g.Operator, g.Value)
+			}
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package operator
 
 const (
-	Equal = "equal"
-	And   = "and"
+	Equal            = "equal"
+	And              = "and"
+	Or               = "or"
+	GreaterThan      = "greaterThan"
+	GreaterThanEqual = "greaterThanEqual"
+	LessThan         = "lessThan"
+	LessThanEqual    = "lessThanEqual"
+	Prefix           = "prefix"
 )
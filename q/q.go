@@ -5,6 +5,12 @@ import (
 	"github.com/leeola/fixity/value"
 )
 
+// idField is the indexed field of mutation ids.
+//
+// This must match index.FIDKey, which cannot be imported here as the
+// index package depends on q.
+const idField = "fid"
+
 type Constraint struct {
 	Operator       string       `json:"operator"`
 	Field          *string      `json:"field,omitempty"`
@@ -14,8 +20,17 @@ type Constraint struct {
 
 type Query struct {
 	IncludeVersions bool
-	LimitBy         int
-	Constraint      Constraint
+
+	// IncludeFields returns the indexed fields of each match, allowing
+	// results to be displayed without reading each match.
+	IncludeFields bool
+	LimitBy       int
+
+	// SkipBy is the number of matches to skip before returning up to
+	// LimitBy matches. Paging with SkipBy is only stable while the
+	// index is not written to between pages.
+	SkipBy     int
+	Constraint Constraint
 }
 
 func New() Query {
@@ -35,6 +50,25 @@ func (q Query) WithoutVersions() Query {
 	return q
 }
 
+// WithFields returns the indexed fields of each match in
+// fixity.Match.Fields.
+func (q Query) WithFields() Query {
+	q.IncludeFields = true
+	return q
+}
+
+func (q Query) Limit(n int) Query {
+	q.LimitBy = n
+	return q
+}
+
+// Skip returns matches after the first n, allowing results to be paged
+// with Limit. For example, the third page of 10 is Skip(20).Limit(10).
+func (q Query) Skip(n int) Query {
+	q.SkipBy = n
+	return q
+}
+
 func (q Query) Const(c Constraint) Query {
 	q.Constraint = c
 	return q
@@ -52,9 +86,112 @@ func Eq(field string, value value.Value) Constraint {
 	}
 }
 
+func Gt(field string, value value.Value) Constraint {
+	return Constraint{
+		Operator: operator.GreaterThan,
+		Field:    &field,
+		Value:    &value,
+	}
+}
+
+func Gte(field string, value value.Value) Constraint {
+	return Constraint{
+		Operator: operator.GreaterThanEqual,
+		Field:    &field,
+		Value:    &value,
+	}
+}
+
+func Lt(field string, value value.Value) Constraint {
+	return Constraint{
+		Operator: operator.LessThan,
+		Field:    &field,
+		Value:    &value,
+	}
+}
+
+func Lte(field string, value value.Value) Constraint {
+	return Constraint{
+		Operator: operator.LessThanEqual,
+		Field:    &field,
+		Value:    &value,
+	}
+}
+
+// Prefix matches string values of the field which begin with prefix.
+func Prefix(field, prefix string) Constraint {
+	v := value.String(prefix)
+	return Constraint{
+		Operator: operator.Prefix,
+		Field:    &field,
+		Value:    &v,
+	}
+}
+
+// IdPrefix matches ids which begin with prefix, such as all ids under
+// "project/" for hierarchical ids like "project/file.txt".
+func IdPrefix(prefix string) Constraint {
+	return Prefix(idField, prefix)
+}
+
+// Id matches the mutations of the given id.
+func Id(id string) Constraint {
+	return Eq(idField, value.String(id))
+}
+
+// IdIn matches the mutations of any of the given ids, allowing several
+// ids to be resolved in one query. Note that the query limit must be
+// at least the number of ids to return all of them.
+func IdIn(ids ...string) Constraint {
+	cs := make([]Constraint, len(ids))
+	for i, id := range ids {
+		cs[i] = Id(id)
+	}
+	return Or(cs...)
+}
+
 func (q Query) And(c ...Constraint) Query {
-	q.Const(And(c...))
-	return q
+	return q.Const(And(c...))
+}
+
+func (q Query) Or(c ...Constraint) Query {
+	return q.Const(Or(c...))
+}
+
+// And returns a constraint requiring both this constraint and all of
+// the given constraints, allowing trees to be built fluently.
+//
+// Constraints compose left to right, so Eq(a).And(Eq(b)).Or(Eq(c)) is
+// (a AND b) OR c, while Eq(a).And(Eq(b).Or(Eq(c))) is a AND (b OR c).
+func (c Constraint) And(cs ...Constraint) Constraint {
+	return join(operator.And, c, cs)
+}
+
+// Or returns a constraint requiring either this constraint or any of
+// the given constraints. See Constraint.And for composition.
+func (c Constraint) Or(cs ...Constraint) Constraint {
+	return join(operator.Or, c, cs)
+}
+
+// join combines c and cs under op, extending c rather than nesting it
+// if c already uses op, as a AND b AND c needs no nesting.
+func join(op string, c Constraint, cs []Constraint) Constraint {
+	if len(cs) == 0 {
+		return c
+	}
+
+	var subs []Constraint
+	if c.Operator == op {
+		subs = append(subs, c.SubConstraints...)
+	} else {
+		subs = append(subs, c)
+	}
+	subs = append(subs, cs...)
+
+	return Constraint{
+		Operator:       op,
+		SubConstraints: subs,
+	}
 }
 
 // And requires that all given constraints are succeed.
@@ -72,3 +209,17 @@ func And(c ...Constraint) Constraint {
 		SubConstraints: c,
 	}
 }
+
+// Or requires that at least one of the given constraints succeed.
+//
+// As with And, a single constraint is returned as is.
+func Or(c ...Constraint) Constraint {
+	if len(c) == 1 {
+		return c[0]
+	}
+
+	return Constraint{
+		Operator:       operator.Or,
+		SubConstraints: c,
+	}
+}
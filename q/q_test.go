@@ -0,0 +1,105 @@
+package q
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leeola/fixity/q/operator"
+	"github.com/leeola/fixity/value"
+)
+
+func TestConstraintComposition(t *testing.T) {
+	a := Eq("a", value.String("1"))
+	b := Eq("b", value.String("2"))
+	c := Eq("c", value.String("3"))
+
+	testCases := []struct {
+		Name   string
+		Got    Constraint
+		Expect Constraint
+	}{
+		{
+			Name: "(a AND b) OR c",
+			Got:  a.And(b).Or(c),
+			Expect: Constraint{
+				Operator: operator.Or,
+				SubConstraints: []Constraint{
+					{Operator: operator.And, SubConstraints: []Constraint{a, b}},
+					c,
+				},
+			},
+		},
+		{
+			Name: "a AND (b OR c)",
+			Got:  a.And(b.Or(c)),
+			Expect: Constraint{
+				Operator: operator.And,
+				SubConstraints: []Constraint{
+					a,
+					{Operator: operator.Or, SubConstraints: []Constraint{b, c}},
+				},
+			},
+		},
+		{
+			Name: "a AND b AND c",
+			Got:  a.And(b).And(c),
+			Expect: Constraint{
+				Operator:       operator.And,
+				SubConstraints: []Constraint{a, b, c},
+			},
+		},
+		{
+			Name:   "Or(a)",
+			Got:    Or(a),
+			Expect: a,
+		},
+		{
+			Name: "Or(And(a, b), c)",
+			Got:  Or(And(a, b), c),
+			Expect: Constraint{
+				Operator: operator.Or,
+				SubConstraints: []Constraint{
+					{Operator: operator.And, SubConstraints: []Constraint{a, b}},
+					c,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		if !reflect.DeepEqual(tc.Got, tc.Expect) {
+			t.Errorf("%s want:%#v, got:%#v", tc.Name, tc.Expect, tc.Got)
+		}
+	}
+}
+
+func TestIdIn(t *testing.T) {
+	id := func(s string) Constraint {
+		field := idField
+		v := value.String(s)
+		return Constraint{Operator: operator.Equal, Field: &field, Value: &v}
+	}
+
+	testCases := []struct {
+		Name   string
+		Got    Constraint
+		Expect Constraint
+	}{
+		{Name: "Id(a)", Got: Id("a"), Expect: id("a")},
+		{Name: "IdIn(a)", Got: IdIn("a"), Expect: id("a")},
+		{
+			Name: "IdIn(a, b, c)",
+			Got:  IdIn("a", "b", "c"),
+			Expect: Constraint{
+				Operator:       operator.Or,
+				SubConstraints: []Constraint{id("a"), id("b"), id("c")},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		if !reflect.DeepEqual(tc.Got, tc.Expect) {
+			t.Errorf("%s want:%#v, got:%#v", tc.Name, tc.Expect, tc.Got)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package fixity
 import "io"
 
 type Reader interface {
-	io.Reader
+	io.ReadCloser
 
 	Size() (int64, error)
 
@@ -0,0 +1,63 @@
+// Package codecreader decompresses data which was written compressed,
+// as recorded by the CodecKey value of the mutation.
+package codecreader
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/leeola/fixity"
+)
+
+// CodecKey is the value recording the compression codec of written
+// data, such as "gzip" for a gzipped upload.
+const CodecKey = "codec"
+
+// Gzip is the CodecKey value of gzip compressed data.
+const Gzip = "gzip"
+
+// decoders maps codec names to constructors of decompressing readers.
+var decoders = map[string]func(io.Reader) (io.ReadCloser, error){
+	Gzip: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+}
+
+// Decompress returns a reader of the decompressed bytes of r, according
+// to the CodecKey of the values. Data without a codec is returned as is.
+//
+// Closing the returned reader closes r.
+func Decompress(v fixity.Values, r io.ReadCloser) (io.ReadCloser, error) {
+	codecValue, ok := v[CodecKey]
+	if !ok {
+		return r, nil
+	}
+
+	codec := codecValue.StringValue
+	newDecoder, ok := decoders[codec]
+	if !ok {
+		return nil, fmt.Errorf("unsupported codec: %q", codec)
+	}
+
+	d, err := newDecoder(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", codec, err)
+	}
+
+	return &readCloser{ReadCloser: d, underlying: r}, nil
+}
+
+// readCloser closes both the decompressor and the underlying reader.
+type readCloser struct {
+	io.ReadCloser
+	underlying io.Closer
+}
+
+func (rc *readCloser) Close() error {
+	err := rc.ReadCloser.Close()
+	if uErr := rc.underlying.Close(); err == nil {
+		err = uErr
+	}
+	return err
+}
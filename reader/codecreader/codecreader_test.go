@@ -0,0 +1,88 @@
+package codecreader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/value"
+)
+
+type closeRecorder struct {
+	*bytes.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestDecompress(t *testing.T) {
+	original := []byte("foo bar baz")
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(original)
+	w.Close()
+
+	testCases := []struct {
+		Name   string
+		Values fixity.Values
+		Data   []byte
+		Expect []byte
+		Err    bool
+	}{
+		{Name: "no codec", Data: original, Expect: original},
+		{Name: "no codec gzip bytes", Data: gz.Bytes(), Expect: gz.Bytes()},
+		{
+			Name:   "gzip",
+			Values: fixity.Values{CodecKey: value.String(Gzip)},
+			Data:   gz.Bytes(),
+			Expect: original,
+		},
+		{
+			Name:   "gzip invalid",
+			Values: fixity.Values{CodecKey: value.String(Gzip)},
+			Data:   original,
+			Err:    true,
+		},
+		{
+			Name:   "unsupported",
+			Values: fixity.Values{CodecKey: value.String("lz4")},
+			Data:   original,
+			Err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		r := &closeRecorder{Reader: bytes.NewReader(tc.Data)}
+		rc, err := Decompress(tc.Values, r)
+		if tc.Err {
+			if err == nil {
+				t.Errorf("%s want error", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s decompress: %v", tc.Name, err)
+		}
+
+		b, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%s readall: %v", tc.Name, err)
+		}
+		if !bytes.Equal(b, tc.Expect) {
+			t.Errorf("%s want:%q, got:%q", tc.Name, tc.Expect, b)
+		}
+
+		if err := rc.Close(); err != nil {
+			t.Errorf("%s close: %v", tc.Name, err)
+		}
+		if !r.closed {
+			t.Errorf("%s underlying reader want closed", tc.Name)
+		}
+	}
+}
@@ -1,14 +1,19 @@
 package datareader
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 
 	"github.com/leeola/fixity"
 	"github.com/leeola/fixity/blobstore"
 )
 
+var errClosed = errors.New("read of closed reader")
+
 type Reader struct {
 	ctx     context.Context
 	bs      fixity.BlobReader
@@ -19,6 +24,21 @@ type Reader struct {
 	partsIndex, partsLength int
 	nextPartsRef            *fixity.Ref
 
+	// visitedParts are the parts refs already followed, to detect cycles.
+	visitedParts map[fixity.Ref]bool
+
+	// offset is the number of content bytes read so far.
+	offset int64
+
+	// partStart is the content offset of the start of the open part.
+	partStart int64
+
+	// done is set once all parts have been read and closed.
+	done bool
+
+	// closed is set by Close, after which reads fail.
+	closed bool
+
 	data fixity.DataSchema
 }
 
@@ -38,6 +58,12 @@ func (r *Reader) dataStruct() error {
 
 	partsLength := len(data.PartsSchema.Parts)
 	if partsLength == 0 {
+		// empty content, such as a zero byte file, has no chunks at all.
+		if data.Size == 0 && data.MoreParts == nil {
+			r.partReadCloser = ioutil.NopCloser(bytes.NewReader(nil))
+			r.data = data
+			return nil
+		}
 		return fmt.Errorf("dataschema %q missing parts", r.dataRef)
 	}
 
@@ -63,6 +89,14 @@ func (r *Reader) nextParts() error {
 		return io.EOF
 	}
 
+	if r.visitedParts[*r.nextPartsRef] {
+		return fmt.Errorf("%w: parts %q", fixity.ErrChainCycle, *r.nextPartsRef)
+	}
+	if r.visitedParts == nil {
+		r.visitedParts = map[fixity.Ref]bool{}
+	}
+	r.visitedParts[*r.nextPartsRef] = true
+
 	var parts fixity.PartsSchema
 	if err := blobstore.ReadAndUnmarshal(r.ctx, r.bs, *r.nextPartsRef, &parts); err != nil {
 		return fmt.Errorf("readandunmarshal: %v", err)
@@ -70,7 +104,7 @@ func (r *Reader) nextParts() error {
 
 	partsLength := len(parts.Parts)
 	if partsLength == 0 {
-		return fmt.Errorf("partschema %q missing parts", r.nextPartsRef)
+		return fmt.Errorf("partschema %q missing parts", *r.nextPartsRef)
 	}
 
 	r.partsIndex = 0
@@ -81,36 +115,60 @@ func (r *Reader) nextParts() error {
 	return nil
 }
 
-func (r *Reader) nextPart() error {
-	// close the previous part if we're trying to load
-	// the next part.
-	if err := r.partReadCloser.Close(); err != nil {
-		return fmt.Errorf("close part: %v", err)
-	}
-
+// nextPartRef advances to the ref of the next part, loading the next
+// parts schema as needed, returning io.EOF after the last part.
+func (r *Reader) nextPartRef() (fixity.Ref, error) {
 	if r.partsIndex == r.partsLength {
 		err := r.nextParts()
 		if err == io.EOF {
-			return io.EOF
+			return "", io.EOF
 		}
 		if err != nil {
-			return fmt.Errorf("nextparts: %v", err)
+			return "", fmt.Errorf("nextparts: %w", err)
 		}
 	}
 
 	ref := r.parts[r.partsIndex]
+	r.partsIndex++
+
+	return ref, nil
+}
+
+func (r *Reader) nextPart() error {
+	// close the previous part if we're trying to load
+	// the next part.
+	if err := r.partReadCloser.Close(); err != nil {
+		return fmt.Errorf("close part: %v", err)
+	}
+
+	ref, err := r.nextPartRef()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return err
+	}
+
 	rc, err := r.bs.Read(r.ctx, ref)
 	if err != nil {
 		return fmt.Errorf("read %q: %v", ref, err)
 	}
 
 	r.partReadCloser = rc
-	r.partsIndex++
+	r.partStart = r.offset
 
 	return nil
 }
 
 func (r *Reader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errClosed
+	}
+
+	if r.done {
+		return 0, io.EOF
+	}
+
 	if r.partReadCloser == nil {
 		if err := r.dataStruct(); err != nil {
 			return 0, fmt.Errorf("dataschema: %v", err)
@@ -118,19 +176,184 @@ func (r *Reader) Read(p []byte) (int, error) {
 	}
 
 	n, err := r.partReadCloser.Read(p)
+	r.offset += int64(n)
 	if err == io.EOF {
 		err := r.nextPart()
 		if err == io.EOF {
+			r.done = true
 			return n, io.EOF
 		}
 		if err != nil {
-			return 0, fmt.Errorf("nextpart: %v", err)
+			return 0, fmt.Errorf("nextpart: %w", err)
 		}
 		return n, nil
 	}
 	return n, err
 }
 
+// Seek implements io.Seeker, allowing random access such as serving
+// range requests with http.ServeContent.
+//
+// Chunk sizes are not recorded in the parts schemas, so seeking forward
+// skips whole chunks by their blob size when the Blobstore is a
+// fixity.BlobSizer, only reading within the chunk containing the
+// offset. Other Blobstores read and discard the chunks before the
+// offset. Seeking backward restarts from the first chunk, and seeking
+// to or past the end reads nothing.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	if r.closed {
+		return 0, errClosed
+	}
+
+	if r.partReadCloser == nil {
+		if err := r.dataStruct(); err != nil {
+			return 0, fmt.Errorf("dataschema: %v", err)
+		}
+	}
+
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.data.Size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+
+	if abs < 0 {
+		return 0, fmt.Errorf("negative position: %d", abs)
+	}
+
+	if abs < r.offset {
+		if err := r.reset(); err != nil {
+			return 0, fmt.Errorf("reset: %v", err)
+		}
+		if err := r.dataStruct(); err != nil {
+			return 0, fmt.Errorf("dataschema: %v", err)
+		}
+	}
+
+	// seeking past the end is allowed, subsequent reads return io.EOF.
+	if abs >= r.data.Size {
+		if !r.done {
+			if err := r.partReadCloser.Close(); err != nil {
+				return 0, fmt.Errorf("close part: %v", err)
+			}
+			r.done = true
+		}
+		r.offset = abs
+		return abs, nil
+	}
+
+	if abs > r.offset {
+		if err := r.skip(abs); err != nil {
+			return 0, fmt.Errorf("skip: %v", err)
+		}
+	}
+
+	return abs, nil
+}
+
+// skip advances the reader forward to the content offset abs, which
+// must be before the end of the data.
+func (r *Reader) skip(abs int64) error {
+	sizer, ok := r.bs.(fixity.BlobSizer)
+	if !ok {
+		if _, err := io.CopyN(ioutil.Discard, r, abs-r.offset); err != nil {
+			return fmt.Errorf("discard: %v", err)
+		}
+		return nil
+	}
+
+	ref := r.parts[r.partsIndex-1]
+	open := true
+	for {
+		size, err := sizer.Size(r.ctx, ref)
+		if err != nil {
+			return fmt.Errorf("size %q: %v", ref, err)
+		}
+		if abs < r.partStart+size {
+			break
+		}
+
+		if open {
+			if err := r.partReadCloser.Close(); err != nil {
+				return fmt.Errorf("close part: %v", err)
+			}
+			open = false
+		}
+
+		r.partStart += size
+		ref, err = r.nextPartRef()
+		if err == io.EOF {
+			return fmt.Errorf("parts end before offset %d", abs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !open {
+		rc, err := r.bs.Read(r.ctx, ref)
+		if err != nil {
+			return fmt.Errorf("read %q: %v", ref, err)
+		}
+		r.partReadCloser = rc
+		r.offset = r.partStart
+	}
+
+	n, err := io.CopyN(ioutil.Discard, r.partReadCloser, abs-r.offset)
+	r.offset += n
+	if err != nil {
+		return fmt.Errorf("discard: %v", err)
+	}
+
+	return nil
+}
+
+// reset closes any open part, causing the next read to start from the
+// first chunk.
+func (r *Reader) reset() error {
+	if r.partReadCloser != nil && !r.done {
+		if err := r.partReadCloser.Close(); err != nil {
+			return fmt.Errorf("close part: %v", err)
+		}
+	}
+
+	r.partReadCloser = nil
+	r.parts = nil
+	r.partsIndex, r.partsLength = 0, 0
+	r.nextPartsRef = nil
+	r.visitedParts = nil
+	r.offset = 0
+	r.partStart = 0
+	r.done = false
+
+	return nil
+}
+
+// Close releases the open chunk reader, if any, allowing consumers to
+// stop reading before the end of the content.
+func (r *Reader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.partReadCloser == nil || r.done {
+		return nil
+	}
+
+	if err := r.partReadCloser.Close(); err != nil {
+		return fmt.Errorf("close part: %v", err)
+	}
+
+	return nil
+}
+
 func (r *Reader) Checksum() (string, error) {
 	if r.partReadCloser == nil {
 		if err := r.dataStruct(); err != nil {
@@ -141,6 +364,28 @@ func (r *Reader) Checksum() (string, error) {
 	return r.data.Checksum, nil
 }
 
+// ChecksumAlgorithm returns the multihash name of the algorithm used
+// for the Checksum.
+func (r *Reader) ChecksumAlgorithm() (string, error) {
+	if r.partReadCloser == nil {
+		if err := r.dataStruct(); err != nil {
+			return "", fmt.Errorf("dataschema: %v", err)
+		}
+	}
+
+	// an empty algorithm means the checksum was made with the same
+	// algorithm that addresses the data blob.
+	if r.data.ChecksumAlgorithm == "" {
+		name, err := r.dataRef.HashName()
+		if err != nil {
+			return "", fmt.Errorf("hashname %q: %v", r.dataRef, err)
+		}
+		return name, nil
+	}
+
+	return r.data.ChecksumAlgorithm, nil
+}
+
 func (r *Reader) Size() (int64, error) {
 	if r.partReadCloser == nil {
 		if err := r.dataStruct(); err != nil {
@@ -0,0 +1,575 @@
+package datareader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore/memory"
+	"github.com/leeola/fixity/chunk"
+	"github.com/leeola/fixity/util/wutil"
+	multihash "github.com/multiformats/go-multihash"
+)
+
+func TestReaderEmpty(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	refs, _, err := wutil.WriteData(ctx, bs, nil, 0, "", fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+
+	r, err := New(ctx, bs, refs[len(refs)-1])
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	size, err := r.Size()
+	if err != nil {
+		t.Fatalf("size: %v", err)
+	}
+	if size != 0 {
+		t.Errorf("size want:0, got:%d", size)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if len(b) != 0 {
+		t.Errorf("read want:0 bytes, got:%d", len(b))
+	}
+}
+
+// sliceChunker chunks the given bytes at a fixed size.
+type sliceChunker struct {
+	b    []byte
+	size int
+}
+
+func (c *sliceChunker) Chunk(_ context.Context) (chunk.Chunk, error) {
+	if len(c.b) == 0 {
+		return chunk.Chunk{}, io.EOF
+	}
+
+	n := c.size
+	if n > len(c.b) {
+		n = len(c.b)
+	}
+
+	b := c.b[:n]
+	c.b = c.b[n:]
+
+	return chunk.Chunk{Bytes: b, Size: int64(n)}, nil
+}
+
+func TestReaderChecksumAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+	content := []byte("foo bar baz")
+
+	chunkRefs, size, checksum, err := wutil.WriteChunks(ctx, bs,
+		&sliceChunker{b: content, size: 4}, "sha2-256")
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+
+	refs, _, err := wutil.WriteData(ctx, bs, chunkRefs, size, checksum, "sha2-256")
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+	dataRef := refs[len(refs)-1]
+
+	hashName, err := dataRef.HashName()
+	if err != nil {
+		t.Fatalf("hashname: %v", err)
+	}
+	if hashName != fixity.DefaultMultihashName {
+		t.Errorf("address algorithm want:%s, got:%s", fixity.DefaultMultihashName, hashName)
+	}
+
+	r, err := New(ctx, bs, dataRef)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	algo, err := r.ChecksumAlgorithm()
+	if err != nil {
+		t.Fatalf("checksumalgorithm: %v", err)
+	}
+	if algo != "sha2-256" {
+		t.Errorf("checksum algorithm want:sha2-256, got:%s", algo)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Errorf("content want:%q, got:%q", content, b)
+	}
+
+	sum := sha256.Sum256(content)
+	if expect := hex.EncodeToString(sum[:]); checksum != expect {
+		t.Errorf("checksum want:%s, got:%s", expect, checksum)
+	}
+	if got, _ := r.Checksum(); got != checksum {
+		t.Errorf("recorded checksum want:%s, got:%s", checksum, got)
+	}
+}
+
+// sha256Blobs is a BlobReader addressing blobs by sha2-256 rather
+// than the fixity.DefaultMultihashName.
+type sha256Blobs map[fixity.Ref][]byte
+
+func (bs sha256Blobs) write(t *testing.T, b []byte) fixity.Ref {
+	sum := sha256.Sum256(b)
+	mh, err := multihash.Encode(sum[:], multihash.Names["sha2-256"])
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	ref := fixity.NewRef(mh)
+	bs[ref] = b
+	return ref
+}
+
+func (bs sha256Blobs) Read(_ context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	b, ok := bs[ref]
+	if !ok {
+		return nil, &fixity.RefError{Op: "read", Ref: ref, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func TestReaderChecksumAlgorithmFromRef(t *testing.T) {
+	ctx := context.Background()
+	bs := sha256Blobs{}
+	content := []byte("foo")
+
+	chunkRef := bs.write(t, content)
+	sum := sha256.Sum256(content)
+	b, err := json.Marshal(fixity.DataSchema{
+		PartsSchema: fixity.PartsSchema{
+			Schema: fixity.Schema{SchemaType: fixity.BlobTypeData},
+			Parts:  []fixity.Ref{chunkRef},
+		},
+		Size:     int64(len(content)),
+		Checksum: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	dataRef := bs.write(t, b)
+
+	r, err := New(ctx, bs, dataRef)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	algo, err := r.ChecksumAlgorithm()
+	if err != nil {
+		t.Fatalf("checksumalgorithm: %v", err)
+	}
+	if algo != "sha2-256" {
+		t.Errorf("checksum algorithm want:sha2-256, got:%s", algo)
+	}
+
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("content want:%q, got:%q", content, got)
+	}
+}
+
+func TestReaderSeek(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	chunkRefs, size, checksum, err := wutil.WriteChunks(ctx, bs,
+		&sliceChunker{b: content, size: 7}, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+
+	refs, _, err := wutil.WriteData(ctx, bs, chunkRefs, size, checksum, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+
+	testCases := []struct {
+		Offset      int64
+		Whence      int
+		ExpectStart int64
+		ReadLen     int
+	}{
+		{Offset: 500, Whence: io.SeekStart, ExpectStart: 500, ReadLen: 20},
+		{Offset: 10, Whence: io.SeekCurrent, ExpectStart: 530, ReadLen: 20},
+		{Offset: 3, Whence: io.SeekStart, ExpectStart: 3, ReadLen: 20},
+		{Offset: 10, Whence: io.SeekEnd, ExpectStart: 1010, ReadLen: 0},
+		{Offset: -5, Whence: io.SeekEnd, ExpectStart: 995, ReadLen: 5},
+	}
+
+	// the memory store is a fixity.BlobSizer, skipping chunks by size,
+	// while the wrapped store only reads.
+	blobReaders := map[string]fixity.BlobReader{
+		"sizer":  bs,
+		"reader": struct{ fixity.BlobReader }{bs},
+	}
+	for name, br := range blobReaders {
+		r, err := New(ctx, br, refs[len(refs)-1])
+		if err != nil {
+			t.Fatalf("%s: new: %v", name, err)
+		}
+
+		for _, testCase := range testCases {
+			pos, err := r.Seek(testCase.Offset, testCase.Whence)
+			if err != nil {
+				t.Fatalf("%s: seek %d: %v", name, testCase.Offset, err)
+			}
+			if pos != testCase.ExpectStart {
+				t.Errorf("%s: seek %d want pos:%d, got:%d", name, testCase.Offset, testCase.ExpectStart, pos)
+			}
+
+			b := make([]byte, testCase.ReadLen)
+			if _, err := io.ReadFull(r, b); err != nil {
+				t.Fatalf("%s: readfull at %d: %v", name, pos, err)
+			}
+
+			// seeking past the end reads nothing.
+			if testCase.ReadLen == 0 {
+				continue
+			}
+
+			expect := content[testCase.ExpectStart : testCase.ExpectStart+int64(testCase.ReadLen)]
+			if !bytes.Equal(b, expect) {
+				t.Errorf("%s: read at %d want:%v, got:%v", name, pos, expect, b)
+			}
+		}
+
+		if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+			t.Errorf("%s: read at end want:0 io.EOF, got:%d %v", name, n, err)
+		}
+	}
+}
+
+// byteCountingStore counts the bytes read from each blob.
+type byteCountingStore struct {
+	*memory.Store
+	read map[fixity.Ref]int64
+}
+
+type byteCountingReadCloser struct {
+	io.ReadCloser
+	s   *byteCountingStore
+	ref fixity.Ref
+}
+
+func (rc byteCountingReadCloser) Read(p []byte) (int, error) {
+	n, err := rc.ReadCloser.Read(p)
+	rc.s.read[rc.ref] += int64(n)
+	return n, err
+}
+
+func (s *byteCountingStore) Read(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	rc, err := s.Store.Read(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return byteCountingReadCloser{ReadCloser: rc, s: s, ref: ref}, nil
+}
+
+func TestReaderSeekSkipsChunks(t *testing.T) {
+	ctx := context.Background()
+	bs := &byteCountingStore{Store: memory.New(), read: map[fixity.Ref]int64{}}
+
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	// chunks of 7 bytes never repeat within 256 chunks, so each chunk
+	// ref is unique.
+	chunkRefs, size, checksum, err := wutil.WriteChunks(ctx, bs,
+		&sliceChunker{b: content, size: 7}, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+
+	refs, _, err := wutil.WriteData(ctx, bs, chunkRefs, size, checksum, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+
+	r, err := New(ctx, bs, refs[len(refs)-1])
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if _, err := r.Seek(990, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(r, b); err != nil {
+		t.Fatalf("readfull: %v", err)
+	}
+	if !bytes.Equal(b, content[990:995]) {
+		t.Errorf("read want:%v, got:%v", content[990:995], b)
+	}
+
+	// 990 is within the chunk at 987, so only the 3 bytes before it are
+	// discarded.
+	target := 990 / 7
+	for i, ref := range chunkRefs[:target] {
+		if n := bs.read[ref]; n != 0 {
+			t.Errorf("chunk %d want 0 bytes read, got:%d", i, n)
+		}
+	}
+	if n := bs.read[chunkRefs[target]]; n != 7 {
+		t.Errorf("target chunk want 7 bytes read, got:%d", n)
+	}
+
+	before := map[fixity.Ref]int64{}
+	for ref, n := range bs.read {
+		before[ref] = n
+	}
+	if _, err := r.Seek(10, io.SeekEnd); err != nil {
+		t.Fatalf("seek end: %v", err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("read past end want:0 io.EOF, got:%d %v", n, err)
+	}
+	if !reflect.DeepEqual(bs.read, before) {
+		t.Errorf("seek end want no bytes read, got:%v", bs.read)
+	}
+}
+
+// openCountingReader counts the blob readers which are still open.
+type openCountingReader struct {
+	fixity.BlobReader
+	open int
+}
+
+type countedReadCloser struct {
+	io.ReadCloser
+	r *openCountingReader
+}
+
+func (rc countedReadCloser) Close() error {
+	rc.r.open--
+	return rc.ReadCloser.Close()
+}
+
+func (r *openCountingReader) Read(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	rc, err := r.BlobReader.Read(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	r.open++
+	return countedReadCloser{ReadCloser: rc, r: r}, nil
+}
+
+func TestReaderClose(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+	content := []byte("foo bar baz")
+
+	chunkRefs, size, checksum, err := wutil.WriteChunks(ctx, bs,
+		&sliceChunker{b: content, size: 4}, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+
+	refs, _, err := wutil.WriteData(ctx, bs, chunkRefs, size, checksum, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+
+	cr := &openCountingReader{BlobReader: bs}
+	r, err := New(ctx, cr, refs[len(refs)-1])
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	// read only part of the first chunk, leaving it open.
+	if _, err := io.ReadFull(r, make([]byte, 2)); err != nil {
+		t.Fatalf("readfull: %v", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if cr.open != 0 {
+		t.Errorf("open readers after close want:0, got:%d", cr.open)
+	}
+
+	if _, err := r.Read(make([]byte, 1)); err == nil {
+		t.Errorf("read after close want error")
+	}
+
+	// closing twice must not double close the chunk reader.
+	if err := r.Close(); err != nil {
+		t.Errorf("second close: %v", err)
+	}
+	if cr.open != 0 {
+		t.Errorf("open readers after second close want:0, got:%d", cr.open)
+	}
+}
+
+// recordingReader records the ref of every blob read.
+type recordingReader struct {
+	fixity.BlobReader
+	reads map[fixity.Ref]int
+}
+
+func (r *recordingReader) Read(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	r.reads[ref]++
+	return r.BlobReader.Read(ctx, ref)
+}
+
+func TestReaderLazyMoreParts(t *testing.T) {
+	ctx := context.Background()
+	bs := memory.New()
+
+	// single byte chunks, spanning the data schema and three more parts.
+	content := make([]byte, 350)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	chunkRefs, size, checksum, err := wutil.WriteChunks(ctx, bs,
+		&sliceChunker{b: content, size: 1}, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+
+	refs, _, err := wutil.WriteData(ctx, bs, chunkRefs, size, checksum, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writedata: %v", err)
+	}
+	partsRefs := refs[len(chunkRefs) : len(refs)-1]
+	if len(partsRefs) != 3 {
+		t.Fatalf("parts blobs want:3, got:%d", len(partsRefs))
+	}
+
+	rr := &recordingReader{BlobReader: bs, reads: map[fixity.Ref]int{}}
+	r, err := New(ctx, rr, refs[len(refs)-1])
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer r.Close()
+
+	partsRead := func() int {
+		var n int
+		for _, ref := range partsRefs {
+			n += rr.reads[ref]
+		}
+		return n
+	}
+
+	testCases := []struct {
+		ReadTo    int
+		PartsRead int
+	}{
+		{ReadTo: 1, PartsRead: 0},
+		{ReadTo: 150, PartsRead: 1},
+		{ReadTo: 250, PartsRead: 2},
+		{ReadTo: 350, PartsRead: 3},
+	}
+
+	var got []byte
+	for _, tc := range testCases {
+		b := make([]byte, tc.ReadTo-len(got))
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatalf("readfull to %d: %v", tc.ReadTo, err)
+		}
+		got = append(got, b...)
+
+		if n := partsRead(); n != tc.PartsRead {
+			t.Errorf("parts read at %d want:%d, got:%d", tc.ReadTo, tc.PartsRead, n)
+		}
+	}
+
+	if !bytes.Equal(got, content) {
+		t.Errorf("content mismatch")
+	}
+
+	for _, ref := range partsRefs {
+		if n := rr.reads[ref]; n != 1 {
+			t.Errorf("parts %q read want:1, got:%d", ref, n)
+		}
+	}
+}
+
+// mapReader serves arbitrary bytes for arbitrary refs, allowing chains
+// that content addressing would otherwise prevent.
+type mapReader map[fixity.Ref][]byte
+
+func (m mapReader) Read(_ context.Context, ref fixity.Ref) (io.ReadCloser, error) {
+	b, ok := m[ref]
+	if !ok {
+		return nil, &fixity.RefError{Op: "read", Ref: ref, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func TestReaderChainCycle(t *testing.T) {
+	marshal := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return b
+	}
+
+	p1, p2 := fixity.Ref("p1"), fixity.Ref("p2")
+	bs := mapReader{
+		"chunk": []byte("foo"),
+		"data": marshal(fixity.DataSchema{
+			PartsSchema: fixity.PartsSchema{
+				Schema:    fixity.Schema{SchemaType: fixity.BlobTypeData},
+				Parts:     []fixity.Ref{"chunk"},
+				MoreParts: &p1,
+			},
+			Size: 9,
+		}),
+		p1: marshal(fixity.PartsSchema{
+			Schema:    fixity.Schema{SchemaType: fixity.BlobTypeParts},
+			Parts:     []fixity.Ref{"chunk"},
+			MoreParts: &p2,
+		}),
+		// p2 links back to p1, forming a cycle.
+		p2: marshal(fixity.PartsSchema{
+			Schema:    fixity.Schema{SchemaType: fixity.BlobTypeParts},
+			Parts:     []fixity.Ref{"chunk"},
+			MoreParts: &p1,
+		}),
+	}
+
+	r, err := New(context.Background(), bs, "data")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	_, err = ioutil.ReadAll(r)
+	if !errors.Is(err, fixity.ErrChainCycle) {
+		t.Errorf("readall want:%v, got:%v", fixity.ErrChainCycle, err)
+	}
+}
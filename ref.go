@@ -28,3 +28,41 @@ func (r Ref) HashName() (string, error) {
 
 	return decoded.Name, nil
 }
+
+// ParseRef parses the given string as a Ref, erroring if it is not a
+// base58 multihash of an algorithm supported by Hasher.
+func ParseRef(s string) (Ref, error) {
+	if s == "" {
+		return "", fmt.Errorf("empty ref")
+	}
+
+	r := Ref(s)
+	name, err := r.HashName()
+	if err != nil {
+		return "", fmt.Errorf("invalid ref %q: %v", s, err)
+	}
+
+	if _, err := Hasher(name); err != nil {
+		return "", fmt.Errorf("invalid ref %q: unsupported algorithm: %s", s, name)
+	}
+
+	return r, nil
+}
+
+// Valid reports whether the Ref is a well formed multihash of a supported
+// algorithm, allowing malformed refs to be rejected before use.
+func (r Ref) Valid() bool {
+	_, err := ParseRef(string(r))
+	return err == nil
+}
+
+// Algo returns the multihash name of the Ref's hash algorithm, or an
+// empty string if the Ref is not Valid.
+func (r Ref) Algo() string {
+	if !r.Valid() {
+		return ""
+	}
+
+	name, _ := r.HashName()
+	return name
+}
@@ -0,0 +1,55 @@
+package fixity
+
+import (
+	"testing"
+
+	multihash "github.com/multiformats/go-multihash"
+)
+
+func TestParseRef(t *testing.T) {
+	blake2bRef, err := Hash([]byte("foo"))
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	// blake2b-512 is a known multihash, but not a fixity supported hasher.
+	mh, err := multihash.Encode(make([]byte, 64), multihash.Names["blake2b-512"])
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	unsupportedRef := NewRef(mh)
+
+	mh, err = multihash.Encode(make([]byte, 32), 0x99)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	unknownRef := NewRef(mh)
+
+	testCases := []struct {
+		ref   Ref
+		valid bool
+		algo  string
+	}{
+		{ref: blake2bRef, valid: true, algo: "blake2b-256"},
+		{ref: unsupportedRef},
+		{ref: unknownRef},
+		{ref: "not-base58-0OIl"},
+		{ref: ""},
+	}
+
+	for _, tc := range testCases {
+		_, err := ParseRef(string(tc.ref))
+		if tc.valid && err != nil {
+			t.Errorf("%q unexpected parse error: %v", tc.ref, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%q want parse error", tc.ref)
+		}
+		if got := tc.ref.Valid(); got != tc.valid {
+			t.Errorf("%q valid want:%t, got:%t", tc.ref, tc.valid, got)
+		}
+		if got := tc.ref.Algo(); got != tc.algo {
+			t.Errorf("%q algo want:%q, got:%q", tc.ref, tc.algo, got)
+		}
+	}
+}
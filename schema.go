@@ -1,5 +1,11 @@
 package fixity
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 type Schema struct {
 	SchemaType BlobType `json:"_fixitySchema"`
 }
@@ -15,14 +21,23 @@ type DataSchema struct {
 	//
 	// Ie, just the raw user uploaded data.
 	//
-	// Hex encoded for user convenience, but using the same hashing algorithm
-	// that the content address of this dataschema. That is to say, if the
-	// content address of this dataschema is a Blake2b multihash, this
-	// checksum will be a plain Blake2b hash, not a multihash.
+	// Hex encoded for user convenience, using the ChecksumAlgorithm if
+	// defined, or the same hashing algorithm as the content address of
+	// this dataschema. That is to say, if the content address of this
+	// dataschema is a Blake2b multihash, this checksum will be a plain
+	// Blake2b hash, not a multihash.
 	//
 	// IMPORTANT: For ease of comparison, this hash string *does not*
 	// include multihash identification prefixes.
 	Checksum string `json:"checksum"`
+
+	// ChecksumAlgorithm is the multihash name of the algorithm used for
+	// the Checksum, if it differs from the content address algorithm.
+	//
+	// Allows a widely recognized checksum, such as sha1 or md5, to be
+	// recorded independent of the content address. If empty, the
+	// algorithm of the data blob's content address was used.
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
 }
 
 type PartsSchema struct {
@@ -35,3 +50,149 @@ type ValuesSchema struct {
 	Schema
 	Values Values `json:"values"`
 }
+
+// BlobDescription is a typed description of what a blob is, and which
+// other blobs it references.
+type BlobDescription struct {
+	Ref  Ref      `json:"ref"`
+	Type BlobType `json:"type"`
+
+	// Size is the number of bytes of the blob itself, not any content
+	// it may reference.
+	Size int64 `json:"size"`
+
+	// Refs are the blobs directly referenced by this blob, if any.
+	Refs []Ref `json:"refs,omitempty"`
+}
+
+// SchemaError lists the structural problems of a blob which do not
+// match its SchemaType.
+type SchemaError struct {
+	Type     BlobType
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Type, strings.Join(e.Problems, "; "))
+}
+
+// ValidateSchema checks that the blob has the fields required by its
+// declared SchemaType, returning a *SchemaError describing every
+// problem found. Corruption or version skew can produce blobs which
+// unmarshal without error, yet are missing required fields.
+//
+// Schemaless blobs have no structure, and are always valid.
+func ValidateSchema(b []byte) error {
+	// failing to unmarshal is considered schemaless, as with Describe.
+	var schema Schema
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil
+	}
+
+	var problems []string
+	switch schema.SchemaType {
+	case BlobTypeSchemaless:
+		return nil
+	case BlobTypeParts:
+		var parts PartsSchema
+		if err := json.Unmarshal(b, &parts); err != nil {
+			problems = append(problems, fmt.Sprintf("unmarshal: %v", err))
+			break
+		}
+		if len(parts.Parts) == 0 {
+			problems = append(problems, "parts is required")
+		}
+		problems = append(problems, partsProblems(parts)...)
+	case BlobTypeData:
+		var data DataSchema
+		if err := json.Unmarshal(b, &data); err != nil {
+			problems = append(problems, fmt.Sprintf("unmarshal: %v", err))
+			break
+		}
+		problems = dataProblems(data)
+	case BlobTypeValues:
+		var values ValuesSchema
+		if err := json.Unmarshal(b, &values); err != nil {
+			problems = append(problems, fmt.Sprintf("unmarshal: %v", err))
+			break
+		}
+		if values.Values == nil {
+			problems = append(problems, "values is required")
+		}
+	case BlobTypeMutation:
+		var mutation Mutation
+		if err := json.Unmarshal(b, &mutation); err != nil {
+			problems = append(problems, fmt.Sprintf("unmarshal: %v", err))
+			break
+		}
+		problems = mutationProblems(mutation)
+	default:
+		problems = append(problems, "unknown schema type")
+	}
+
+	if len(problems) != 0 {
+		return &SchemaError{Type: schema.SchemaType, Problems: problems}
+	}
+
+	return nil
+}
+
+func partsProblems(p PartsSchema) []string {
+	var problems []string
+	for i, ref := range p.Parts {
+		if !ref.Valid() {
+			problems = append(problems, fmt.Sprintf("parts[%d] is not a valid ref", i))
+		}
+	}
+	if p.MoreParts != nil && !p.MoreParts.Valid() {
+		problems = append(problems, "moreParts is not a valid ref")
+	}
+	return problems
+}
+
+func dataProblems(d DataSchema) []string {
+	problems := partsProblems(d.PartsSchema)
+
+	// empty content has no parts, so either may be zero but not only
+	// one of them.
+	if len(d.Parts) == 0 && d.Size != 0 {
+		problems = append(problems, "parts is required for non-zero size")
+	}
+	if len(d.Parts) != 0 && d.Size <= 0 {
+		problems = append(problems, "size is required")
+	}
+	if d.MoreParts != nil && len(d.Parts) == 0 {
+		problems = append(problems, "moreParts without parts")
+	}
+
+	if d.Checksum == "" {
+		problems = append(problems, "checksum is required")
+	}
+	if d.ChecksumAlgorithm != "" {
+		if _, err := Hasher(d.ChecksumAlgorithm); err != nil {
+			problems = append(problems, fmt.Sprintf("checksumAlgorithm: %v", err))
+		}
+	}
+
+	return problems
+}
+
+func mutationProblems(m Mutation) []string {
+	var problems []string
+	if m.ID == "" {
+		problems = append(problems, "id is required")
+	}
+	if m.Time.IsZero() {
+		problems = append(problems, "time is required")
+	}
+	if m.ValuesSchema == "" && m.DataSchema == "" {
+		problems = append(problems, "valuesSchema or dataSchema is required")
+	}
+	if m.ValuesSchema != "" && !m.ValuesSchema.Valid() {
+		problems = append(problems, "valuesSchema is not a valid ref")
+	}
+	if m.DataSchema != "" && !m.DataSchema.Valid() {
+		problems = append(problems, "dataSchema is not a valid ref")
+	}
+	return problems
+}
@@ -0,0 +1,95 @@
+package fixity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateSchema(t *testing.T) {
+	ref, err := Hash([]byte("foo"))
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+
+	marshal := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return b
+	}
+
+	testCases := []struct {
+		Name     string
+		Blob     []byte
+		Problems int
+	}{
+		{Name: "schemaless", Blob: []byte("foo")},
+		{Name: "data", Blob: marshal(DataSchema{
+			PartsSchema: PartsSchema{
+				Schema: Schema{SchemaType: BlobTypeData},
+				Parts:  []Ref{ref},
+			},
+			Size:     3,
+			Checksum: "abc",
+		})},
+		{Name: "empty data", Blob: marshal(DataSchema{
+			PartsSchema: PartsSchema{Schema: Schema{SchemaType: BlobTypeData}},
+			Checksum:    "abc",
+		})},
+		{Name: "data missing parts and checksum", Problems: 2, Blob: []byte(
+			`{"_fixitySchema":2,"size":3}`)},
+		{Name: "data missing size", Problems: 1, Blob: marshal(DataSchema{
+			PartsSchema: PartsSchema{
+				Schema: Schema{SchemaType: BlobTypeData},
+				Parts:  []Ref{ref},
+			},
+			Checksum: "abc",
+		})},
+		{Name: "data invalid part", Problems: 1, Blob: marshal(DataSchema{
+			PartsSchema: PartsSchema{
+				Schema: Schema{SchemaType: BlobTypeData},
+				Parts:  []Ref{ref, "bar"},
+			},
+			Size:     3,
+			Checksum: "abc",
+		})},
+		{Name: "data wrong field type", Problems: 1, Blob: []byte(
+			`{"_fixitySchema":2,"parts":"foo"}`)},
+		{Name: "parts missing parts", Problems: 1, Blob: []byte(
+			`{"_fixitySchema":1}`)},
+		{Name: "values missing values", Problems: 1, Blob: []byte(
+			`{"_fixitySchema":3}`)},
+		{Name: "mutation", Blob: marshal(Mutation{
+			Schema:     Schema{SchemaType: BlobTypeMutation},
+			ID:         "foo",
+			Time:       time.Unix(1, 0),
+			DataSchema: ref,
+		})},
+		{Name: "mutation missing fields", Problems: 3, Blob: []byte(
+			`{"_fixitySchema":4}`)},
+		{Name: "unknown type", Problems: 1, Blob: []byte(
+			`{"_fixitySchema":99}`)},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateSchema(tc.Blob)
+		if tc.Problems == 0 {
+			if err != nil {
+				t.Errorf("%s want valid, got:%v", tc.Name, err)
+			}
+			continue
+		}
+
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Errorf("%s want SchemaError, got:%v", tc.Name, err)
+			continue
+		}
+		if len(schemaErr.Problems) != tc.Problems {
+			t.Errorf("%s want %d problems, got:%v", tc.Name, tc.Problems, schemaErr.Problems)
+		}
+	}
+}
@@ -7,9 +7,15 @@ import (
 
 type Store interface {
 	Blob(ctx context.Context, ref Ref) (io.ReadCloser, error)
+	Describe(ctx context.Context, ref Ref) (BlobDescription, error)
 	Read(ctx context.Context, id string) (Mutation, Values, Reader, error)
 	ReadRef(context.Context, Ref) (Mutation, Values, Reader, error)
 	Write(ctx context.Context, id string, v Values, r io.Reader) ([]Ref, error)
 	WriteNamespace(ctx context.Context, id, namespace string, v Values, r io.Reader) ([]Ref, error)
+
+	// Sync flushes any buffered writes of the store, its blobstore and
+	// its index to stable storage.
+	Sync() error
+
 	Querier
 }
@@ -9,6 +9,7 @@ const configType = "nosign"
 
 func init() {
 	fixity.RegisterStore(configType, fixity.StoreConstructorFunc(Constructor))
+	config.RegisterConfigType(configType, Config{})
 }
 
 func Constructor(name string, c config.Config) (fixity.Store, error) {
@@ -5,30 +5,84 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/leeola/fixity"
 	"github.com/leeola/fixity/blobstore"
+	"github.com/leeola/fixity/chunk"
 	"github.com/leeola/fixity/chunk/resticfork"
 	"github.com/leeola/fixity/config"
 	"github.com/leeola/fixity/index"
 	"github.com/leeola/fixity/q"
 	"github.com/leeola/fixity/reader/datareader"
 	"github.com/leeola/fixity/util/wutil"
-	"github.com/leeola/fixity/value"
 )
 
 type Config struct {
-	BlobstoreName string `json:"blobstoreName"`
-	IndexName     string `json:"indexName"`
+	BlobstoreName string `json:"blobstoreName" validate:"required"`
+	IndexName     string `json:"indexName" validate:"required"`
+
+	// ChecksumAlgorithm is the multihash name used for data checksums,
+	// independent of the content address algorithm.
+	//
+	// Defaults to fixity.DefaultMultihashName.
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
+
+	// WriteConcurrency is the maximum number of chunk writes in flight
+	// at once, hiding blobstore write latency for large writes.
+	//
+	// Defaults to sequential writes.
+	WriteConcurrency int `json:"writeConcurrency,omitempty" validate:"min=0"`
+
+	// Chunker is the registered name of the chunk.Chunker used to split
+	// written data.
+	//
+	// Defaults to resticfork.
+	Chunker string `json:"chunker,omitempty"`
+
+	// ParallelChunkSize, if set, writes data from readers of a known
+	// size which are also io.ReaderAts, such as regular files, as fixed
+	// size chunks of this many bytes, read and written in parallel up to
+	// WriteConcurrency. Other readers use the Chunker.
+	//
+	// Fixed size chunks do not deduplicate against content written with
+	// the Chunker, so this trades dedup for write throughput.
+	//
+	// Defaults to always using the Chunker.
+	ParallelChunkSize int64 `json:"parallelChunkSize,omitempty" validate:"min=0"`
 }
 
 type Store struct {
 	// embedded because the store exposes the same methods.
 	index.Querier
 
-	bstor fixity.Blobstore
-	index index.Indexer
+	bstor            fixity.Blobstore
+	index            index.Indexer
+	checksumName     string
+	writeConcurrency int
+	chunkerName      string
+	parallelChunk    int64
+
+	// conditionalMu serializes conditional writes, so that the
+	// constraint query and the write are not interleaved with another
+	// conditional write.
+	conditionalMu sync.Mutex
+
+	// idLocks serializes writes of the same id, so that mutations of an
+	// id are indexed in the order of their times. Writes of different
+	// ids are not serialized.
+	idLocksMu sync.Mutex
+	idLocks   map[string]*idLock
+}
+
+// idLock is the write lock of a single id, removed from idLocks once no
+// writes of the id are waiting.
+type idLock struct {
+	mu   sync.Mutex
+	refs int
 }
 
 func New(name string, fc config.Config) (*Store, error) {
@@ -37,6 +91,10 @@ func New(name string, fc config.Config) (*Store, error) {
 		return nil, fmt.Errorf("unmarshal config: %v", err)
 	}
 
+	if err := config.Validate(c); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+
 	bs, err := fixity.NewBlobstoreFromConfig(c.BlobstoreName, fc)
 	if err != nil {
 		return nil, fmt.Errorf("blobstoreFromConfig: %v", err)
@@ -47,7 +105,50 @@ func New(name string, fc config.Config) (*Store, error) {
 		return nil, fmt.Errorf("indexFromConfig: %v", err)
 	}
 
-	return &Store{bstor: bs, index: ix, Querier: ix}, nil
+	checksumName := c.ChecksumAlgorithm
+	if checksumName == "" {
+		checksumName = fixity.DefaultMultihashName
+	}
+
+	// fail early on unsupported algorithms, rather than on first write.
+	if _, err := fixity.Hasher(checksumName); err != nil {
+		return nil, fmt.Errorf("checksum algorithm: %v", err)
+	}
+
+	chunkerName := c.Chunker
+	if chunkerName == "" {
+		chunkerName = resticfork.Name
+	}
+
+	if !chunk.Registered(chunkerName) {
+		return nil, fmt.Errorf("chunker not found: %q", chunkerName)
+	}
+
+	// a cold index only slows the first query, so failing to warm it
+	// is not fatal.
+	if err := warmIndex(ix); err != nil && fc.Log {
+		log.Printf("warm index %q: %v", c.IndexName, err)
+	}
+
+	return &Store{
+		bstor:            bs,
+		index:            ix,
+		Querier:          ix,
+		checksumName:     checksumName,
+		writeConcurrency: c.WriteConcurrency,
+		chunkerName:      chunkerName,
+		parallelChunk:    c.ParallelChunkSize,
+	}, nil
+}
+
+// warmIndex warms the index, if it supports warming.
+func warmIndex(ix interface{}) error {
+	w, ok := ix.(index.Warmer)
+	if !ok {
+		return nil
+	}
+
+	return w.Warm()
 }
 
 func (s *Store) Write(ctx context.Context, id string, v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
@@ -56,12 +157,54 @@ func (s *Store) Write(ctx context.Context, id string, v fixity.Values, r io.Read
 }
 
 func (s *Store) WriteNamespace(ctx context.Context, id, namespace string, v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
-	return s.WriteTimeNamespace(ctx, time.Now(), id, namespace, v, r)
+	unlock := s.lockID(id)
+	defer unlock()
+
+	// time is taken within the lock, ensuring mutations of the id are
+	// indexed in time order.
+	return s.writeTimeNamespace(ctx, time.Now(), id, namespace, v, r)
 }
 
 func (s *Store) WriteTimeNamespace(ctx context.Context,
 	t time.Time, id, namespace string, v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
 
+	unlock := s.lockID(id)
+	defer unlock()
+
+	return s.writeTimeNamespace(ctx, t, id, namespace, v, r)
+}
+
+// lockID locks writes of the given id, returning the func to unlock it.
+func (s *Store) lockID(id string) (unlock func()) {
+	s.idLocksMu.Lock()
+	if s.idLocks == nil {
+		s.idLocks = map[string]*idLock{}
+	}
+	l, ok := s.idLocks[id]
+	if !ok {
+		l = &idLock{}
+		s.idLocks[id] = l
+	}
+	l.refs++
+	s.idLocksMu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		s.idLocksMu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.idLocks, id)
+		}
+		s.idLocksMu.Unlock()
+	}
+}
+
+func (s *Store) writeTimeNamespace(ctx context.Context,
+	t time.Time, id, namespace string, v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
+
 	if v == nil && r == nil {
 		return nil, errors.New("values and data cannot be nil")
 	}
@@ -73,17 +216,13 @@ func (s *Store) WriteTimeNamespace(ctx context.Context,
 		dataRef fixity.Ref
 	)
 	if r != nil {
-		chunker, err := resticfork.New(r, resticfork.DefaultAverageChunkSize)
+		cHashes, totalSize, checksum, err := s.writeChunks(ctx, r)
 		if err != nil {
-			return nil, fmt.Errorf("restic new: %v", err)
+			return nil, err // no wrap helper err
 		}
 
-		cHashes, totalSize, checksum, err := wutil.WriteChunks(ctx, s.bstor, chunker)
-		if err != nil {
-			return nil, fmt.Errorf("writechunker: %v", err)
-		}
-
-		cHashes, d, err := wutil.WriteData(ctx, s.bstor, cHashes, totalSize, checksum)
+		cHashes, d, err := wutil.WriteDataConcurrent(ctx, s.bstor, cHashes, totalSize, checksum,
+			s.checksumName, s.writeConcurrency)
 		if err != nil {
 			return nil, fmt.Errorf("writecontent: %v", err)
 		}
@@ -125,6 +264,128 @@ func (s *Store) WriteTimeNamespace(ctx context.Context,
 	return append(refs, ref), nil
 }
 
+// writeChunks writes the chunks of r, in parallel at fixed boundaries if
+// configured and r supports it, otherwise with the chunker.
+func (s *Store) writeChunks(ctx context.Context, r io.Reader) (
+	[]fixity.Ref, int64, string, error) {
+
+	if s.parallelChunk > 0 {
+		if ra, size, ok := readerAtSize(r); ok {
+			refs, totalSize, checksum, err := wutil.WriteReaderAt(ctx, s.bstor, ra, size,
+				s.parallelChunk, s.checksumName, s.writeConcurrency)
+			if err != nil {
+				return nil, 0, "", fmt.Errorf("writereaderat: %v", err)
+			}
+			return refs, totalSize, checksum, nil
+		}
+	}
+
+	chunker, err := chunk.New(s.chunkerName, r)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("chunker new: %v", err)
+	}
+
+	refs, totalSize, checksum, err := wutil.WriteChunksConcurrent(ctx, s.bstor, chunker,
+		s.checksumName, s.writeConcurrency)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("writechunker: %v", err)
+	}
+
+	return refs, totalSize, checksum, nil
+}
+
+// readerAtSize returns r as an io.ReaderAt along with the size of its
+// remaining content, if r is a regular file or reports its size, such
+// as a bytes.Reader.
+//
+// Only readers at their start are used, as ReadAt offsets are absolute
+// and would include content the caller already read.
+func readerAtSize(r io.Reader) (io.ReaderAt, int64, bool) {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return nil, 0, false
+	}
+
+	var size int64
+	switch sr := r.(type) {
+	case *os.File:
+		fi, err := sr.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return nil, 0, false
+		}
+		pos, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil || pos != 0 {
+			return nil, 0, false
+		}
+		size = fi.Size()
+	case interface{ Size() int64 }:
+		// bytes and strings readers report the full size, so confirm
+		// nothing has been read with Len.
+		if lr, ok := r.(interface{ Len() int }); !ok || int64(lr.Len()) != sr.Size() {
+			return nil, 0, false
+		}
+		size = sr.Size()
+	default:
+		return nil, 0, false
+	}
+
+	return ra, size, true
+}
+
+// WriteIfAbsent writes the values and data only if the absent query has
+// no matches, returning fixity.ErrConstraintViolation otherwise. This
+// allows uniqueness constraints, such as only one record per email.
+//
+// Conditional writes within this Store are serialized, so two
+// WriteIfAbsent calls cannot both pass the same constraint. However
+// unconditional writes, and writes by other processes sharing the
+// index, may still race between the query and the write.
+func (s *Store) WriteIfAbsent(ctx context.Context, id string, absent q.Query,
+	v fixity.Values, r io.Reader) ([]fixity.Ref, error) {
+
+	s.conditionalMu.Lock()
+	defer s.conditionalMu.Unlock()
+
+	matches, err := s.Query(absent.Limit(1))
+	if err != nil {
+		return nil, fmt.Errorf("query constraint: %v", err)
+	}
+
+	if len(matches) > 0 {
+		return nil, fixity.ErrConstraintViolation
+	}
+
+	return s.Write(ctx, id, v, r)
+}
+
+// Explain describes how the index executes the query, if the index
+// supports it.
+func (s *Store) Explain(qu q.Query) (index.Explanation, error) {
+	e, ok := s.Querier.(index.Explainer)
+	if !ok {
+		return index.Explanation{}, errors.New("index does not support explain")
+	}
+
+	return e.Explain(qu)
+}
+
+// Sync flushes the blobstore and index, if either buffers writes.
+func (s *Store) Sync() error {
+	if syncer, ok := s.bstor.(fixity.Syncer); ok {
+		if err := syncer.Sync(); err != nil {
+			return fmt.Errorf("blobstore sync: %v", err)
+		}
+	}
+
+	if syncer, ok := s.index.(fixity.Syncer); ok {
+		if err := syncer.Sync(); err != nil {
+			return fmt.Errorf("index sync: %v", err)
+		}
+	}
+
+	return nil
+}
+
 func (s *Store) Blob(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error) {
 	rc, err := s.bstor.Read(ctx, ref)
 	if err != nil {
@@ -136,10 +397,14 @@ func (s *Store) Blob(ctx context.Context, ref fixity.Ref) (io.ReadCloser, error)
 	return rc, nil
 }
 
+func (s *Store) Describe(ctx context.Context, ref fixity.Ref) (fixity.BlobDescription, error) {
+	return blobstore.Describe(ctx, s.bstor, ref)
+}
+
 func (s *Store) Read(ctx context.Context, id string) (
 	fixity.Mutation, fixity.Values, fixity.Reader, error) {
 
-	matches, err := s.Query(q.New().Eq(index.FIDKey, value.String(id)))
+	matches, err := s.Query(q.New().Const(q.Id(id)))
 	if err != nil {
 		return fixity.Mutation{}, nil, nil, fmt.Errorf("query id: %v", err)
 	}
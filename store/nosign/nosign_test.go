@@ -0,0 +1,439 @@
+package nosign
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore"
+	"github.com/leeola/fixity/blobstore/memory"
+	"github.com/leeola/fixity/chunk"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/index/bleve"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/q/operator"
+	"github.com/leeola/fixity/value"
+)
+
+// eqIndex is a minimal in memory index supporting only Eq queries.
+type eqIndex struct {
+	mu      sync.Mutex
+	matches []fixity.Match
+	values  []fixity.Values
+}
+
+func (ix *eqIndex) Index(ref fixity.Ref, m fixity.Mutation, _ *fixity.DataSchema, v fixity.Values) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.matches = append(ix.matches, fixity.Match{ID: m.ID, Ref: ref})
+	ix.values = append(ix.values, v)
+	return nil
+}
+
+func (ix *eqIndex) Query(qu q.Query) ([]fixity.Match, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	c := qu.Constraint
+	if c.Operator != operator.Equal {
+		return nil, nil
+	}
+
+	var matches []fixity.Match
+	for i, v := range ix.values {
+		if fv, ok := v[*c.Field]; ok && fv.StringValue == c.Value.StringValue {
+			matches = append(matches, ix.matches[i])
+		}
+	}
+	return matches, nil
+}
+
+func TestWriteIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	ix := &eqIndex{}
+	s := &Store{
+		Querier:      ix,
+		bstor:        memory.New(),
+		index:        ix,
+		checksumName: fixity.DefaultMultihashName,
+	}
+
+	email := value.String("foo@example.com")
+	unique := q.New().Eq("email", email)
+
+	if _, err := s.WriteIfAbsent(ctx, "a", unique, fixity.Values{"email": email}, nil); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+
+	_, err := s.WriteIfAbsent(ctx, "b", unique, fixity.Values{"email": email}, nil)
+	if err != fixity.ErrConstraintViolation {
+		t.Errorf("second write want:%v, got:%v", fixity.ErrConstraintViolation, err)
+	}
+
+	if len(ix.matches) != 1 {
+		t.Errorf("indexed mutations want:1, got:%d", len(ix.matches))
+	}
+}
+
+// fixedChunker chunks at a fixed size, for deterministic boundaries.
+type fixedChunker struct {
+	r    io.Reader
+	size int
+}
+
+func (c *fixedChunker) Chunk(_ context.Context) (chunk.Chunk, error) {
+	b := make([]byte, c.size)
+	n, err := io.ReadFull(c.r, b)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if err != nil {
+		return chunk.Chunk{}, err
+	}
+	return chunk.Chunk{Bytes: b[:n], Size: int64(n)}, nil
+}
+
+func TestWriteChunker(t *testing.T) {
+	ctx := context.Background()
+	ix := &eqIndex{}
+	s := &Store{
+		Querier:      ix,
+		bstor:        memory.New(),
+		index:        ix,
+		checksumName: fixity.DefaultMultihashName,
+		chunkerName:  "fixedtest",
+	}
+
+	refs, err := s.Write(ctx, "foo", nil, strings.NewReader("abcdefghij"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	m, _, r, err := s.ReadRef(ctx, refs[len(refs)-1])
+	if err != nil {
+		t.Fatalf("readref: %v", err)
+	}
+	defer r.Close()
+
+	var data fixity.DataSchema
+	if err := blobstore.ReadAndUnmarshal(ctx, s.bstor, m.DataSchema, &data); err != nil {
+		t.Fatalf("read data: %v", err)
+	}
+
+	var want []fixity.Ref
+	for _, c := range []string{"abcd", "efgh", "ij"} {
+		ref, err := fixity.Hash([]byte(c))
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		want = append(want, ref)
+	}
+
+	if !reflect.DeepEqual(data.Parts, want) {
+		t.Errorf("parts want:%v, got:%v", want, data.Parts)
+	}
+}
+
+func TestWriteParallelChunkSize(t *testing.T) {
+	ctx := context.Background()
+	ix := &eqIndex{}
+	s := &Store{
+		Querier:          ix,
+		bstor:            memory.New(),
+		index:            ix,
+		checksumName:     fixity.DefaultMultihashName,
+		chunkerName:      "fixedtest",
+		writeConcurrency: 4,
+		parallelChunk:    3,
+	}
+
+	testCases := []struct {
+		Name   string
+		Reader io.Reader
+		Chunks []string
+	}{
+		{"readerat", strings.NewReader("abcdefghij"), []string{"abc", "def", "ghi", "j"}},
+		// readers without ReadAt fall back to the chunker.
+		{"reader", io.MultiReader(strings.NewReader("abcdefghij")), []string{"abcd", "efgh", "ij"}},
+	}
+
+	for _, tc := range testCases {
+		refs, err := s.Write(ctx, tc.Name, nil, tc.Reader)
+		if err != nil {
+			t.Fatalf("%s: write: %v", tc.Name, err)
+		}
+
+		m, _, r, err := s.ReadRef(ctx, refs[len(refs)-1])
+		if err != nil {
+			t.Fatalf("%s: readref: %v", tc.Name, err)
+		}
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: readall: %v", tc.Name, err)
+		}
+		if string(b) != "abcdefghij" {
+			t.Errorf("%s: content want:abcdefghij, got:%q", tc.Name, b)
+		}
+
+		var data fixity.DataSchema
+		if err := blobstore.ReadAndUnmarshal(ctx, s.bstor, m.DataSchema, &data); err != nil {
+			t.Fatalf("%s: read data: %v", tc.Name, err)
+		}
+
+		var want []fixity.Ref
+		for _, c := range tc.Chunks {
+			ref, err := fixity.Hash([]byte(c))
+			if err != nil {
+				t.Fatalf("hash: %v", err)
+			}
+			want = append(want, ref)
+		}
+		if !reflect.DeepEqual(data.Parts, want) {
+			t.Errorf("%s: parts want:%v, got:%v", tc.Name, want, data.Parts)
+		}
+	}
+}
+
+func TestWriteEmptyReader(t *testing.T) {
+	ctx := context.Background()
+
+	hasher, err := fixity.Hasher(fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("hasher: %v", err)
+	}
+	emptyChecksum := hex.EncodeToString(hasher.Sum(nil))
+
+	testCases := []struct {
+		Name          string
+		ParallelChunk int64
+		Reader        io.Reader
+	}{
+		{"chunker", 0, io.MultiReader()},
+		{"readerat", 4, strings.NewReader("")},
+	}
+
+	for _, tc := range testCases {
+		ix := &eqIndex{}
+		s := &Store{
+			Querier:       ix,
+			bstor:         memory.New(),
+			index:         ix,
+			checksumName:  fixity.DefaultMultihashName,
+			chunkerName:   "fixedtest",
+			parallelChunk: tc.ParallelChunk,
+		}
+
+		refs, err := s.Write(ctx, "empty", nil, tc.Reader)
+		if err != nil {
+			t.Fatalf("%s: write: %v", tc.Name, err)
+		}
+
+		_, _, r, err := s.ReadRef(ctx, refs[len(refs)-1])
+		if err != nil {
+			t.Fatalf("%s: readref: %v", tc.Name, err)
+		}
+		if r == nil {
+			t.Fatalf("%s: readref want empty data, got no data", tc.Name)
+		}
+
+		size, err := r.Size()
+		if err != nil {
+			t.Fatalf("%s: size: %v", tc.Name, err)
+		}
+		if size != 0 {
+			t.Errorf("%s: size want:0, got:%d", tc.Name, size)
+		}
+
+		checksum, err := r.Checksum()
+		if err != nil {
+			t.Fatalf("%s: checksum: %v", tc.Name, err)
+		}
+		if checksum != emptyChecksum {
+			t.Errorf("%s: checksum want:%s, got:%s", tc.Name, emptyChecksum, checksum)
+		}
+
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: readall: %v", tc.Name, err)
+		}
+		if len(b) != 0 {
+			t.Errorf("%s: read want:0 bytes, got:%d", tc.Name, len(b))
+		}
+	}
+}
+
+func TestWriteSameIDSerialized(t *testing.T) {
+	ctx := context.Background()
+	ix := &eqIndex{}
+	s := &Store{
+		Querier:      ix,
+		bstor:        memory.New(),
+		index:        ix,
+		checksumName: fixity.DefaultMultihashName,
+	}
+
+	const writes = 50
+	var wg sync.WaitGroup
+	for i := 0; i < writes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v := fixity.Values{"n": value.Int(i)}
+			if _, err := s.Write(ctx, "foo", v, nil); err != nil {
+				t.Errorf("write %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(ix.matches) != writes {
+		t.Fatalf("indexed mutations want:%d, got:%d", writes, len(ix.matches))
+	}
+
+	// each mutation must be indexed after the mutation before it,
+	// otherwise the index could end on an older mutation of the id.
+	var last time.Time
+	for i, m := range ix.matches {
+		var mutation fixity.Mutation
+		if err := blobstore.ReadAndUnmarshal(ctx, s.bstor, m.Ref, &mutation); err != nil {
+			t.Fatalf("read mutation %d: %v", i, err)
+		}
+		if mutation.Time.Before(last) {
+			t.Errorf("mutation %d indexed out of order: %s before %s", i, mutation.Time, last)
+		}
+		last = mutation.Time
+	}
+
+	if len(s.idLocks) != 0 {
+		t.Errorf("id locks want released, got:%d", len(s.idLocks))
+	}
+}
+
+type warmingIndex struct {
+	eqIndex
+	warmed int
+}
+
+func (ix *warmingIndex) Warm() error {
+	ix.warmed++
+	return nil
+}
+
+// testWarmingIndex is returned by the "nosigntest" index constructor.
+var testWarmingIndex = &warmingIndex{}
+
+func init() {
+	chunk.Register("fixedtest", func(r io.Reader) (chunk.Chunker, error) {
+		return &fixedChunker{r: r, size: 4}, nil
+	})
+	fixity.RegisterBlobstore("nosigntest", fixity.BlobstoreConstructorFunc(
+		func(string, config.Config) (fixity.Blobstore, error) {
+			return memory.New(), nil
+		}))
+	fixity.RegisterIndex("nosigntest", fixity.IndexConstructorFunc(
+		func(string, config.Config) (fixity.Index, error) {
+			return testWarmingIndex, nil
+		}))
+}
+
+func TestWarmIndex(t *testing.T) {
+	fc := config.Config{
+		BlobstoreConfigs: map[string]config.TypeConfig{
+			"bs": {Type: "nosigntest"},
+		},
+		IndexConfigs: map[string]config.TypeConfig{
+			"ix": {Type: "nosigntest"},
+		},
+		StoreConfigs: map[string]config.TypeConfig{
+			"test": {
+				Type:   "nosign",
+				Config: []byte(`{"blobstoreName":"bs","indexName":"ix"}`),
+			},
+		},
+	}
+
+	before := testWarmingIndex.warmed
+	if _, err := New("test", fc); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := testWarmingIndex.warmed - before; got != 1 {
+		t.Errorf("warm calls during new want:1, got:%d", got)
+	}
+
+	// indexes which do not warm are skipped.
+	if err := warmIndex(&eqIndex{}); err != nil {
+		t.Errorf("non warmer want nil, got:%v", err)
+	}
+}
+
+func TestExcludedFieldsStored(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "fixity-nosign")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ix, err := bleve.New("test", config.Config{
+		RootPath: dir,
+		IndexConfigs: map[string]config.TypeConfig{
+			"test": {Type: "bleve", Config: []byte(`{"path":"index","excludeFields":["password"]}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("bleve new: %v", err)
+	}
+
+	s := &Store{
+		Querier:      ix,
+		bstor:        memory.New(),
+		index:        ix,
+		checksumName: fixity.DefaultMultihashName,
+	}
+
+	v := fixity.Values{
+		"user":     value.String("foo"),
+		"password": value.String("hunter2"),
+	}
+	if _, err := s.Write(ctx, "foo", v, nil); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// excluded from the index, but still stored in the values blob.
+	_, got, _, err := s.Read(ctx, "foo")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got["password"].StringValue != "hunter2" {
+		t.Errorf("stored password want:hunter2, got:%v", got["password"])
+	}
+
+	testCases := []struct {
+		Field, Value string
+		Matches      int
+	}{
+		{"user", "foo", 1},
+		{"password", "hunter2", 0},
+	}
+	for _, tc := range testCases {
+		matches, err := s.Query(q.New().Eq(tc.Field, value.String(tc.Value)))
+		if err != nil {
+			t.Fatalf("query %s: %v", tc.Field, err)
+		}
+		if len(matches) != tc.Matches {
+			t.Errorf("query %s matches want:%d, got:%d", tc.Field, tc.Matches, len(matches))
+		}
+	}
+}
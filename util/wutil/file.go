@@ -0,0 +1,67 @@
+package wutil
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/value"
+)
+
+// Keys of the values returned by FileValues.
+const (
+	FilenameKey = "filename"
+	SizeKey     = "size"
+	MimeKey     = "mime"
+	ModTimeKey  = "modtime"
+)
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// FileValues extracts basic metadata of the file to be indexed along
+// with its content, such as the filename, size, detected mime type and
+// modification time.
+//
+// The file is read to detect the mime type, and then seeked back to
+// the start so that it can be written.
+//
+// Video files in an ISO base media container, such as mp4 and mov, also
+// have their duration, resolution and codecs probed. Other containers,
+// such as Matroska, and malformed files are not probed.
+func FileValues(f *os.File) (fixity.Values, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat: %v", err)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("read head: %v", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek: %v", err)
+	}
+
+	values := fixity.Values{
+		FilenameKey: value.String(filepath.Base(fi.Name())),
+		SizeKey:     value.Int(int(fi.Size())),
+		MimeKey:     value.String(http.DetectContentType(head[:n])),
+		ModTimeKey:  value.Time(fi.ModTime()),
+	}
+
+	// a video which cannot be probed can still be written, just
+	// without its video values.
+	if vv, err := videoValues(f, fi.Size()); err == nil {
+		for k, v := range vv {
+			values[k] = v
+		}
+	}
+
+	return values, nil
+}
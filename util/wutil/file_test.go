@@ -0,0 +1,244 @@
+package wutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/config"
+	"github.com/leeola/fixity/index/bleve"
+	"github.com/leeola/fixity/q"
+	"github.com/leeola/fixity/value"
+)
+
+func TestFileValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixity-wutil")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "foo.html")
+	content := "<html><body>foo</body></html>"
+	if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatalf("writefile: %v", err)
+	}
+
+	modTime := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	v, err := FileValues(f)
+	if err != nil {
+		t.Fatalf("filevalues: %v", err)
+	}
+
+	if got := v[FilenameKey].StringValue; got != "foo.html" {
+		t.Errorf("filename want:foo.html, got:%s", got)
+	}
+	if got, _ := v.Int(SizeKey); got != len(content) {
+		t.Errorf("size want:%d, got:%d", len(content), got)
+	}
+	if got := v[MimeKey].StringValue; got != "text/html; charset=utf-8" {
+		t.Errorf("mime want:text/html; charset=utf-8, got:%s", got)
+	}
+	if got, err := v[ModTimeKey].TimeValue(); err != nil || !got.Equal(modTime) {
+		t.Errorf("modtime want:%s, got:%s (%v)", modTime, got, err)
+	}
+
+	// the file must be rewound so the full content can still be written.
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatalf("readall: %v", err)
+	}
+	if string(b) != content {
+		t.Errorf("content want:%q, got:%q", content, b)
+	}
+}
+
+// mp4Box returns an ISO base media file format box of the body.
+func mp4Box(typ string, body ...[]byte) []byte {
+	b := bytes.Join(body, nil)
+	h := make([]byte, 8, 8+len(b))
+	binary.BigEndian.PutUint32(h, uint32(8+len(b)))
+	copy(h[4:], typ)
+	return append(h, b...)
+}
+
+// mp4Track returns a trak box of the handler type and sample entry
+// code, with the given resolution in its track header.
+func mp4Track(handler, codec string, width, height uint32) []byte {
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], width<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], height<<16)
+
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:], handler)
+
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:], 1)
+
+	return mp4Box("trak",
+		mp4Box("tkhd", tkhd),
+		mp4Box("mdia",
+			mp4Box("hdlr", hdlr),
+			mp4Box("minf",
+				mp4Box("stbl",
+					mp4Box("stsd", stsd, mp4Box(codec, make([]byte, 8))))),
+		),
+	)
+}
+
+// testMP4 is a minimal mp4 of the given duration, with the media data
+// before the movie box, as is common for recorded video.
+func testMP4(seconds uint32, width, height uint32) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], seconds*1000+500)
+
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		mp4Box("mdat", make([]byte, 1024)),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Track("vide", "avc1", width, height),
+			mp4Track("soun", "mp4a", 0, 0),
+		),
+	}, nil)
+}
+
+func TestFileVideoValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixity-wutil")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	malformed := testMP4(90, 320, 240)
+	// truncate the moov box, leaving its size past the end of the file.
+	malformed = malformed[:len(malformed)-10]
+
+	testCases := []struct {
+		Name    string
+		Content []byte
+		Expect  fixity.Values
+	}{
+		{"video.mp4", testMP4(90, 320, 240), fixity.Values{
+			DurationKey: value.Int(90),
+			WidthKey:    value.Int(320),
+			HeightKey:   value.Int(240),
+			CodecsKey:   value.List(value.String("avc1"), value.String("mp4a")),
+		}},
+		{"malformed.mp4", malformed, fixity.Values{}},
+		{"foo.txt", []byte("foo"), fixity.Values{}},
+	}
+
+	for _, tc := range testCases {
+		p := filepath.Join(dir, tc.Name)
+		if err := ioutil.WriteFile(p, tc.Content, 0644); err != nil {
+			t.Fatalf("writefile: %v", err)
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		v, err := FileValues(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: filevalues: %v", tc.Name, err)
+		}
+
+		for _, k := range []string{DurationKey, WidthKey, HeightKey, CodecsKey} {
+			expect, ok := tc.Expect[k]
+			got, gotOK := v[k]
+			if ok != gotOK || !reflect.DeepEqual(expect, got) {
+				t.Errorf("%s: %s want:%v, got:%v", tc.Name, k, expect, got)
+			}
+		}
+		if got := v[FilenameKey].StringValue; got != tc.Name {
+			t.Errorf("%s: filename want:%s, got:%s", tc.Name, tc.Name, got)
+		}
+	}
+}
+
+func TestFileValuesIndexed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixity-wutil")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ix, err := bleve.New("test", config.Config{
+		RootPath: dir,
+		IndexConfigs: map[string]config.TypeConfig{
+			"test": {Type: "bleve", Config: []byte(`{"path":"index"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("bleve new: %v", err)
+	}
+
+	files := map[string]string{
+		"foo.html":  "<html><body>foo</body></html>",
+		"bar.txt":   "bar",
+		"short.mp4": string(testMP4(30, 320, 240)),
+		"long.mp4":  string(testMP4(90, 1920, 1080)),
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("writefile: %v", err)
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		v, err := FileValues(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("filevalues: %v", err)
+		}
+
+		ref := fixity.Ref(name + "-ref")
+		if err := ix.Index(ref, fixity.Mutation{ID: name}, nil, v); err != nil {
+			t.Fatalf("index: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		Query q.Query
+		ID    string
+	}{
+		{q.New().Eq(FilenameKey, value.String("foo.html")), "foo.html"},
+		// mime values are analyzed into words, such as text and html.
+		{q.New().Eq(MimeKey, value.String("html")), "foo.html"},
+		{q.New().Eq(FilenameKey, value.String("bar.txt")), "bar.txt"},
+		{q.New().Const(q.Gt(DurationKey, value.Int(60))), "long.mp4"},
+		{q.New().Const(q.Lt(WidthKey, value.Int(1000))), "short.mp4"},
+	}
+
+	for _, tc := range testCases {
+		matches, err := ix.Query(tc.Query)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if len(matches) != 1 || matches[0].ID != tc.ID {
+			t.Errorf("query %v want:%s, got:%v", tc.Query.Constraint, tc.ID, matches)
+		}
+	}
+}
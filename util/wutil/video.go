@@ -0,0 +1,245 @@
+package wutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/value"
+)
+
+// Keys of the video values returned by FileValues.
+const (
+	// DurationKey is the duration of the video in whole seconds.
+	DurationKey = "duration"
+	WidthKey    = "width"
+	HeightKey   = "height"
+
+	// CodecsKey is the list of sample entry codes of the video and
+	// audio tracks, such as avc1 and mp4a.
+	CodecsKey = "codecs"
+)
+
+// maxBoxBody is the most bytes of a metadata box body read. The fields
+// read are all within the first 100 bytes.
+const maxBoxBody = 128
+
+// box is an ISO base media file format box, the container structure of
+// mp4 and quicktime files, with the offsets of its body.
+type box struct {
+	typ        string
+	start, end int64
+}
+
+// videoValues probes the ISO base media file format container of r,
+// such as an mp4, m4v or mov file, for its duration, resolution and
+// codecs.
+//
+// Nil values are returned if r is not such a container. Only the
+// headers of boxes are read, skipping their media data.
+func videoValues(r io.ReaderAt, size int64) (fixity.Values, error) {
+	// every such container starts with an ftyp box.
+	var h [8]byte
+	if _, err := r.ReadAt(h[:], 0); err != nil || string(h[4:]) != "ftyp" {
+		return nil, nil
+	}
+
+	top, err := readBoxes(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+
+	moov, ok := findBox(top, "moov")
+	if !ok {
+		return nil, errors.New("missing moov box")
+	}
+
+	moovBoxes, err := readBoxes(r, moov.start, moov.end)
+	if err != nil {
+		return nil, fmt.Errorf("moov: %v", err)
+	}
+
+	mvhd, err := readBoxPath(r, moovBoxes, "mvhd")
+	if err != nil {
+		return nil, err
+	}
+	duration, err := movieDuration(mvhd)
+	if err != nil {
+		return nil, fmt.Errorf("mvhd: %v", err)
+	}
+
+	values := fixity.Values{
+		DurationKey: value.Int(int(duration)),
+	}
+
+	var codecs []value.Value
+	for _, trak := range moovBoxes {
+		if trak.typ != "trak" {
+			continue
+		}
+
+		trakBoxes, err := readBoxes(r, trak.start, trak.end)
+		if err != nil {
+			return nil, fmt.Errorf("trak: %v", err)
+		}
+
+		hdlr, err := readBoxPath(r, trakBoxes, "mdia", "hdlr")
+		if err != nil {
+			return nil, err
+		}
+		if len(hdlr) < 12 {
+			return nil, errors.New("hdlr: too short")
+		}
+		handler := string(hdlr[8:12])
+		if handler != "vide" && handler != "soun" {
+			continue
+		}
+
+		stsd, err := readBoxPath(r, trakBoxes, "mdia", "minf", "stbl", "stsd")
+		if err != nil {
+			return nil, err
+		}
+		if len(stsd) >= 16 {
+			codecs = append(codecs, value.String(string(stsd[12:16])))
+		}
+
+		// the resolution is that of the first video track.
+		if _, ok := values[WidthKey]; handler == "vide" && !ok {
+			tkhd, err := readBoxPath(r, trakBoxes, "tkhd")
+			if err != nil {
+				return nil, err
+			}
+			width, height, err := trackResolution(tkhd)
+			if err != nil {
+				return nil, fmt.Errorf("tkhd: %v", err)
+			}
+			values[WidthKey] = value.Int(width)
+			values[HeightKey] = value.Int(height)
+		}
+	}
+
+	if len(codecs) != 0 {
+		values[CodecsKey] = value.List(codecs...)
+	}
+
+	return values, nil
+}
+
+// readBoxes returns the boxes between the start and end offsets of r.
+func readBoxes(r io.ReaderAt, start, end int64) ([]box, error) {
+	var boxes []box
+	for off := start; off+8 <= end; {
+		var h [16]byte
+		if _, err := r.ReadAt(h[:8], off); err != nil {
+			return nil, fmt.Errorf("read header at %d: %v", off, err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(h[:4]))
+		typ := string(h[4:8])
+		bodyStart := off + 8
+		switch size {
+		case 0:
+			// the box extends to the end of its parent.
+			size = end - off
+		case 1:
+			if _, err := r.ReadAt(h[8:], off+8); err != nil {
+				return nil, fmt.Errorf("read large size at %d: %v", off, err)
+			}
+			size = int64(binary.BigEndian.Uint64(h[8:]))
+			bodyStart = off + 16
+		}
+
+		if size < bodyStart-off || size > end-off {
+			return nil, fmt.Errorf("box %q at %d: invalid size %d", typ, off, size)
+		}
+
+		boxes = append(boxes, box{typ: typ, start: bodyStart, end: off + size})
+		off += size
+	}
+
+	return boxes, nil
+}
+
+func findBox(boxes []box, typ string) (box, bool) {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+// readBoxPath reads up to maxBoxBody bytes of the body of the box at
+// the path of box types, the first within boxes.
+func readBoxPath(r io.ReaderAt, boxes []box, path ...string) ([]byte, error) {
+	var b box
+	for i, typ := range path {
+		var ok bool
+		b, ok = findBox(boxes, typ)
+		if !ok {
+			return nil, fmt.Errorf("missing %s box", typ)
+		}
+
+		if i < len(path)-1 {
+			var err error
+			boxes, err = readBoxes(r, b.start, b.end)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", typ, err)
+			}
+		}
+	}
+
+	n := b.end - b.start
+	if n > maxBoxBody {
+		n = maxBoxBody
+	}
+
+	body := make([]byte, n)
+	if _, err := r.ReadAt(body, b.start); err != nil {
+		return nil, fmt.Errorf("read %s body: %v", b.typ, err)
+	}
+
+	return body, nil
+}
+
+// movieDuration returns the duration in seconds of an mvhd box body.
+func movieDuration(mvhd []byte) (uint64, error) {
+	var timescale, duration uint64
+	switch {
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	case len(mvhd) >= 20 && mvhd[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	default:
+		return 0, errors.New("unsupported version or too short")
+	}
+
+	if timescale == 0 {
+		return 0, errors.New("zero timescale")
+	}
+
+	return duration / timescale, nil
+}
+
+// trackResolution returns the width and height of a tkhd box body,
+// which are 16.16 fixed point numbers.
+func trackResolution(tkhd []byte) (int, int, error) {
+	var off int
+	switch {
+	case len(tkhd) >= 96 && tkhd[0] == 1:
+		off = 88
+	case len(tkhd) >= 84 && tkhd[0] == 0:
+		off = 76
+	default:
+		return 0, 0, errors.New("unsupported version or too short")
+	}
+
+	width := binary.BigEndian.Uint32(tkhd[off:off+4]) >> 16
+	height := binary.BigEndian.Uint32(tkhd[off+4:off+8]) >> 16
+
+	return int(width), int(height), nil
+}
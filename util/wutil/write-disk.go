@@ -1,88 +1,212 @@
 package wutil
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"sync"
 
 	"github.com/leeola/fixity"
 	"github.com/leeola/fixity/chunk"
+	"github.com/leeola/fixity/value"
 )
 
 const partSize = 100
 
-func WriteData(ctx context.Context, w fixity.BlobWriter, chunkRefs []fixity.Ref, totalSize int64, contentHash string) ([]fixity.Ref, *fixity.DataSchema, error) {
+// WriteData writes the parts and data schemas for the given chunkRefs.
+//
+// checksumName is the multihash name of the algorithm that produced the
+// contentHash, and is only recorded if it differs from the
+// fixity.DefaultMultihashName.
+func WriteData(ctx context.Context, w fixity.BlobWriter, chunkRefs []fixity.Ref, totalSize int64, contentHash, checksumName string) ([]fixity.Ref, *fixity.DataSchema, error) {
 
-	chunkRefLen := len(chunkRefs)
+	firstPage, pages := dataPages(chunkRefs)
 
-	// -1 ensures that the morePartCount doesn't increase at an equal divide,
-	// like 2 items for a pagesize of 2, would only need 1 page, yet morePartCount
-	// would indicate that there's a morePart page as well.
-	//
-	//
-	// I feel like that made no sense.
-	morePartCount := (chunkRefLen - 1) / partSize
+	// copied rather than appended to, so the caller's slice is never
+	// written to.
+	refs := make([]fixity.Ref, 0, len(chunkRefs)+len(pages)+1)
+	refs = append(refs, chunkRefs...)
 
-	var lastPart *fixity.Ref
+	// pages are written last to first, as each page must reference the
+	// ref of the page after it.
+	var moreParts *fixity.Ref
+	for i := len(pages) - 1; i >= 0; i-- {
+		part := fixity.PartsSchema{
+			Schema: fixity.Schema{
+				SchemaType: fixity.BlobTypeParts,
+			},
+			Parts:     pages[i],
+			MoreParts: moreParts,
+		}
 
-	// write all of the parts first, including the partial final part..
-	// ie, the part that has less than the max chunks.
-	for i := morePartCount; i > 0; i-- {
-		startBound := partSize * i
-		endBound := startBound + partSize
-		if i == morePartCount {
-			endBound = startBound + chunkRefLen%partSize
+		ref, err := MarshalAndWrite(ctx, w, part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshalandwrite part %d: %v", i+1, err)
 		}
+		refs = append(refs, ref)
+		moreParts = &ref
+	}
 
-		part := fixity.PartsSchema{
+	data := fixity.DataSchema{
+		PartsSchema: fixity.PartsSchema{
+			Schema: fixity.Schema{
+				SchemaType: fixity.BlobTypeData,
+			},
+			Parts:     firstPage,
+			MoreParts: moreParts,
+		},
+		Size:     totalSize,
+		Checksum: contentHash,
+	}
+	if checksumName != fixity.DefaultMultihashName {
+		data.ChecksumAlgorithm = checksumName
+	}
+
+	ref, err := MarshalAndWrite(ctx, w, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshalandwrite content: %v", err)
+	}
+
+	return append(refs, ref), &data, nil
+}
+
+// dataPages splits chunkRefs into the first page, embedded in the data
+// schema, and the following pages of up to partSize refs, each written
+// as a parts schema linked from the page before it by MoreParts.
+func dataPages(chunkRefs []fixity.Ref) (first []fixity.Ref, pages [][]fixity.Ref) {
+	firstEnd := partSize
+	if len(chunkRefs) < partSize {
+		firstEnd = len(chunkRefs)
+	}
+
+	for start := firstEnd; start < len(chunkRefs); start += partSize {
+		end := start + partSize
+		if end > len(chunkRefs) {
+			end = len(chunkRefs)
+		}
+		pages = append(pages, chunkRefs[start:end])
+	}
+
+	return chunkRefs[:firstEnd], pages
+}
+
+// WriteDataConcurrent is WriteData with up to concurrency parts schemas
+// written at once, for data with many parts.
+//
+// Each part must reference the ref of the part after it, so refs are
+// computed with fixity.Hash before writing, and the blobstore must
+// address blobs with fixity.Hash. The written blobs and returned refs
+// are identical to WriteData.
+func WriteDataConcurrent(ctx context.Context, w fixity.BlobWriter, chunkRefs []fixity.Ref,
+	totalSize int64, contentHash, checksumName string, concurrency int) ([]fixity.Ref, *fixity.DataSchema, error) {
+
+	if concurrency <= 1 {
+		return WriteData(ctx, w, chunkRefs, totalSize, contentHash, checksumName)
+	}
+
+	firstPage, pages := dataPages(chunkRefs)
+
+	refs := make([]fixity.Ref, 0, len(chunkRefs)+len(pages)+1)
+	refs = append(refs, chunkRefs...)
+
+	// marshal and hash from last to first, as with WriteData, without
+	// waiting on any writes.
+	blobs := make([][]byte, 0, len(pages))
+	var moreParts *fixity.Ref
+	for i := len(pages) - 1; i >= 0; i-- {
+		b, err := json.Marshal(fixity.PartsSchema{
 			Schema: fixity.Schema{
 				SchemaType: fixity.BlobTypeParts,
 			},
-			Parts:     chunkRefs[startBound:endBound],
-			MoreParts: lastPart,
+			Parts:     pages[i],
+			MoreParts: moreParts,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal part %d: %v", i+1, err)
 		}
 
-		ref, err := MarshalAndWrite(ctx, w, part)
+		ref, err := fixity.Hash(b)
 		if err != nil {
-			return nil, nil, fmt.Errorf("marshalandwrite part %d: %v", i, err)
+			return nil, nil, fmt.Errorf("hash part %d: %v", i+1, err)
 		}
-		chunkRefs = append(chunkRefs, ref)
-		lastPart = &ref
+
+		blobs = append(blobs, b)
+		refs = append(refs, ref)
+		moreParts = &ref
+	}
+
+	var (
+		gate     = make(chan struct{}, concurrency)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		writeErr error
+	)
+
+	partRefs := refs[len(chunkRefs):]
+	for i, b := range blobs {
+		gate <- struct{}{}
+		wg.Add(1)
+		go func(i int, b []byte) {
+			defer func() {
+				<-gate
+				wg.Done()
+			}()
+
+			ref, err := w.Write(ctx, b)
+			if err == nil && ref != partRefs[i] {
+				err = fmt.Errorf("blobstore ref %s does not match hash %s", ref, partRefs[i])
+			}
+
+			if err != nil {
+				mu.Lock()
+				if writeErr == nil {
+					writeErr = fmt.Errorf("blob write part: %v", err)
+				}
+				mu.Unlock()
+			}
+		}(i, b)
 	}
 
-	endBound := partSize
-	if chunkRefLen < partSize {
-		endBound = chunkRefLen
+	wg.Wait()
+
+	if writeErr != nil {
+		return nil, nil, writeErr
 	}
 
-	// now we've written all the parts except for the most important
-	// one, the content which has a part embedded.
 	data := fixity.DataSchema{
 		PartsSchema: fixity.PartsSchema{
 			Schema: fixity.Schema{
 				SchemaType: fixity.BlobTypeData,
 			},
-			Parts:     chunkRefs[0:endBound],
-			MoreParts: lastPart,
+			Parts:     firstPage,
+			MoreParts: moreParts,
 		},
 		Size:     totalSize,
 		Checksum: contentHash,
 	}
+	if checksumName != fixity.DefaultMultihashName {
+		data.ChecksumAlgorithm = checksumName
+	}
 
 	ref, err := MarshalAndWrite(ctx, w, data)
 	if err != nil {
 		return nil, nil, fmt.Errorf("marshalandwrite content: %v", err)
 	}
 
-	return append(chunkRefs, ref), &data, nil
+	return append(refs, ref), &data, nil
 }
 
-func WriteChunks(ctx context.Context, w fixity.BlobWriter, r chunk.Chunker) (
+// WriteChunks writes all chunks from the chunker, returning the chunk
+// refs, total size, and the hex checksum of all chunk bytes using the
+// checksumName algorithm.
+func WriteChunks(ctx context.Context, w fixity.BlobWriter, r chunk.Chunker, checksumName string) (
 	refs []fixity.Ref, totalSize int64, contentHash string, err error) {
 
-	hasher, err := fixity.Hasher(fixity.DefaultMultihashName)
+	hasher, err := fixity.Hasher(checksumName)
 	if err != nil {
 		return nil, 0, "", fmt.Errorf("hasher: %v", err)
 	}
@@ -116,6 +240,203 @@ func WriteChunks(ctx context.Context, w fixity.BlobWriter, r chunk.Chunker) (
 	return hashes, totalSize, hash, nil
 }
 
+// WriteChunksConcurrent is WriteChunks with up to concurrency chunk
+// writes in flight at once, hiding store write latency.
+//
+// Chunks are still rolled and checksummed sequentially, and the
+// returned refs are in chunk order regardless of which write finishes
+// first, so the result is identical to WriteChunks. A concurrency of 1
+// or less uses the sequential WriteChunks.
+func WriteChunksConcurrent(ctx context.Context, w fixity.BlobWriter, r chunk.Chunker, checksumName string, concurrency int) (
+	refs []fixity.Ref, totalSize int64, contentHash string, err error) {
+
+	if concurrency <= 1 {
+		return WriteChunks(ctx, w, r, checksumName)
+	}
+
+	hasher, err := fixity.Hasher(checksumName)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("hasher: %v", err)
+	}
+
+	var (
+		gate     = make(chan struct{}, concurrency)
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		hashes   []fixity.Ref
+		writeErr error
+	)
+
+	for i := 0; ; i++ {
+		mu.Lock()
+		err := writeErr
+		mu.Unlock()
+		if err != nil {
+			break
+		}
+
+		c, err := r.Chunk(ctx)
+		if err != nil && err != io.EOF {
+			wg.Wait()
+			return nil, 0, "", fmt.Errorf("chunk: %v", err)
+		}
+
+		totalSize += c.Size
+
+		if err == io.EOF {
+			break
+		}
+
+		if _, err := hasher.Write(c.Bytes); err != nil {
+			wg.Wait()
+			return nil, 0, "", fmt.Errorf("hasher write: %v", err)
+		}
+
+		// chunkers may reuse their buffer for the next chunk.
+		b := make([]byte, len(c.Bytes))
+		copy(b, c.Bytes)
+
+		mu.Lock()
+		hashes = append(hashes, "")
+		mu.Unlock()
+
+		gate <- struct{}{}
+		wg.Add(1)
+		go func(i int, b []byte) {
+			defer func() {
+				<-gate
+				wg.Done()
+			}()
+
+			h, err := w.Write(ctx, b)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if writeErr == nil {
+					writeErr = fmt.Errorf("blob write chunk %d: %v", i, err)
+				}
+				return
+			}
+			hashes[i] = h
+		}(i, b)
+	}
+
+	wg.Wait()
+
+	if writeErr != nil {
+		return nil, 0, "", writeErr
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil)[:])
+	return hashes, totalSize, hash, nil
+}
+
+// WriteReaderAt writes size bytes of ra as fixed size chunks, reading
+// and writing up to concurrency chunks in parallel, and returns the same
+// results as WriteChunks.
+//
+// Chunk boundaries are fixed at every chunkSize bytes rather than
+// content defined, as content defined boundaries depend on all prior
+// bytes and cannot be found in parallel. The output is deterministic
+// for a given chunkSize, but it will not deduplicate against the same
+// content written with a content defined chunker, nor survive insertions
+// shifting later boundaries.
+func WriteReaderAt(ctx context.Context, w fixity.BlobWriter, ra io.ReaderAt, size, chunkSize int64,
+	checksumName string, concurrency int) (refs []fixity.Ref, totalSize int64, contentHash string, err error) {
+
+	if chunkSize <= 0 {
+		return nil, 0, "", fmt.Errorf("chunk size must be positive, got %d", chunkSize)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	hasher, err := fixity.Hasher(checksumName)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("hasher: %v", err)
+	}
+
+	type result struct {
+		b   []byte
+		ref fixity.Ref
+		err error
+	}
+
+	var (
+		chunkCount = int((size + chunkSize - 1) / chunkSize)
+		results    = make([]chan result, chunkCount)
+		// gate is held from the start of a chunk read until its result
+		// is consumed, bounding the chunks held in memory.
+		gate = make(chan struct{}, concurrency)
+		done = make(chan struct{})
+	)
+	defer close(done)
+
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	go func() {
+		for i := 0; i < chunkCount; i++ {
+			select {
+			case gate <- struct{}{}:
+			case <-done:
+				return
+			}
+
+			go func(i int) {
+				off := int64(i) * chunkSize
+				n := chunkSize
+				if off+n > size {
+					n = size - off
+				}
+
+				b := make([]byte, n)
+				read, err := ra.ReadAt(b, off)
+				if err != nil && err != io.EOF {
+					results[i] <- result{err: fmt.Errorf("readat chunk %d: %v", i, err)}
+					return
+				}
+				// a short read means size exceeds the content, and the
+				// zeroed tail of b must not be written as content.
+				if int64(read) != n {
+					results[i] <- result{err: fmt.Errorf("readat chunk %d: read %d of %d bytes: %v",
+						i, read, n, io.ErrUnexpectedEOF)}
+					return
+				}
+
+				ref, err := w.Write(ctx, b)
+				if err != nil {
+					err = fmt.Errorf("blob write chunk %d: %v", i, err)
+				}
+				results[i] <- result{b: b, ref: ref, err: err}
+			}(i)
+		}
+	}()
+
+	// consume in order, so that the checksum covers the chunks in order.
+	hashes := make([]fixity.Ref, chunkCount)
+	for i := range results {
+		res := <-results[i]
+		<-gate
+
+		if res.err != nil {
+			return nil, 0, "", res.err
+		}
+
+		if _, err := hasher.Write(res.b); err != nil {
+			return nil, 0, "", fmt.Errorf("hasher write: %v", err)
+		}
+
+		hashes[i] = res.ref
+		totalSize += int64(len(res.b))
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil)[:])
+	return hashes, totalSize, hash, nil
+}
+
 func MarshalAndWrite(ctx context.Context, w fixity.BlobWriter, v interface{}) (fixity.Ref, error) {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -130,12 +451,24 @@ func MarshalAndWrite(ctx context.Context, w fixity.BlobWriter, v interface{}) (f
 	return ref, nil
 }
 
+// WriteValues writes the values schema of v.
+//
+// Values is a map, which encoding/json marshals with sorted keys, so
+// equal values have the same ref regardless of insertion order. List
+// values are unordered, such as repeated --kv flags, so they are
+// written sorted, giving the same ref regardless of their order.
+// The given values are not modified.
 func WriteValues(ctx context.Context, w fixity.BlobWriter, v fixity.Values) (fixity.Ref, error) {
+	sorted, err := sortedValues(v)
+	if err != nil {
+		return "", fmt.Errorf("sort values: %v", err)
+	}
+
 	vs := fixity.ValuesSchema{
 		Schema: fixity.Schema{
 			SchemaType: fixity.BlobTypeValues,
 		},
-		Values: v,
+		Values: sorted,
 	}
 
 	ref, err := MarshalAndWrite(ctx, w, vs)
@@ -145,3 +478,57 @@ func WriteValues(ctx context.Context, w fixity.BlobWriter, v fixity.Values) (fix
 
 	return ref, nil
 }
+
+// sortedValues returns a copy of v with all list values, including
+// nested lists, sorted by their json encoding.
+func sortedValues(v fixity.Values) (fixity.Values, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	sorted := make(fixity.Values, len(v))
+	for k, fv := range v {
+		sv, err := sortedValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		sorted[k] = sv
+	}
+
+	return sorted, nil
+}
+
+func sortedValue(v value.Value) (value.Value, error) {
+	if v.Type != value.TypeList {
+		return v, nil
+	}
+
+	type keyed struct {
+		key []byte
+		v   value.Value
+	}
+
+	l := make([]keyed, len(v.ListValue))
+	for i, lv := range v.ListValue {
+		sv, err := sortedValue(lv)
+		if err != nil {
+			return value.Value{}, err
+		}
+		b, err := json.Marshal(sv)
+		if err != nil {
+			return value.Value{}, fmt.Errorf("marshal: %v", err)
+		}
+		l[i] = keyed{key: b, v: sv}
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		return bytes.Compare(l[i].key, l[j].key) < 0
+	})
+
+	vs := make([]value.Value, len(l))
+	for i, kv := range l {
+		vs[i] = kv.v
+	}
+
+	return value.List(vs...), nil
+}
@@ -0,0 +1,394 @@
+package wutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leeola/fixity"
+	"github.com/leeola/fixity/blobstore/memory"
+	"github.com/leeola/fixity/chunk"
+	"github.com/leeola/fixity/value"
+)
+
+// sliceChunker chunks the given bytes at a fixed size, reusing a single
+// buffer like real chunkers.
+type sliceChunker struct {
+	b    []byte
+	size int
+	buf  []byte
+}
+
+func (c *sliceChunker) Chunk(_ context.Context) (chunk.Chunk, error) {
+	if len(c.b) == 0 {
+		return chunk.Chunk{}, io.EOF
+	}
+
+	n := c.size
+	if n > len(c.b) {
+		n = len(c.b)
+	}
+
+	if c.buf == nil {
+		c.buf = make([]byte, c.size)
+	}
+	copy(c.buf, c.b[:n])
+	c.b = c.b[n:]
+
+	return chunk.Chunk{Bytes: c.buf[:n], Size: int64(n)}, nil
+}
+
+// latencyWriter delays every write, simulating a slow blobstore.
+type latencyWriter struct {
+	fixity.BlobWriter
+	latency time.Duration
+}
+
+func (w latencyWriter) Write(ctx context.Context, b []byte) (fixity.Ref, error) {
+	time.Sleep(w.latency)
+	return w.BlobWriter.Write(ctx, b)
+}
+
+func testContent(size int) []byte {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte(i * 7)
+	}
+	return b
+}
+
+func TestWriteChunksConcurrent(t *testing.T) {
+	ctx := context.Background()
+	content := testContent(10000)
+
+	expectRefs, expectSize, expectHash, err := WriteChunks(ctx, memory.New(),
+		&sliceChunker{b: content, size: 64}, fixity.DefaultMultihashName)
+	if err != nil {
+		t.Fatalf("writechunks: %v", err)
+	}
+
+	for _, concurrency := range []int{0, 1, 2, 8, 64} {
+		refs, size, hash, err := WriteChunksConcurrent(ctx, memory.New(),
+			&sliceChunker{b: content, size: 64}, fixity.DefaultMultihashName, concurrency)
+		if err != nil {
+			t.Fatalf("concurrency %d: writechunksconcurrent: %v", concurrency, err)
+		}
+		if !reflect.DeepEqual(refs, expectRefs) {
+			t.Errorf("concurrency %d: refs differ from sequential write", concurrency)
+		}
+		if size != expectSize {
+			t.Errorf("concurrency %d: size want:%d, got:%d", concurrency, expectSize, size)
+		}
+		if hash != expectHash {
+			t.Errorf("concurrency %d: hash want:%s, got:%s", concurrency, expectHash, hash)
+		}
+	}
+}
+
+func TestWriteReaderAt(t *testing.T) {
+	ctx := context.Background()
+
+	for _, contentSize := range []int{0, 64, 10000} {
+		content := testContent(contentSize)
+
+		expectRefs, expectSize, expectHash, err := WriteChunks(ctx, memory.New(),
+			&sliceChunker{b: content, size: 64}, fixity.DefaultMultihashName)
+		if err != nil {
+			t.Fatalf("writechunks: %v", err)
+		}
+
+		for _, concurrency := range []int{1, 8} {
+			bs := memory.New()
+			refs, size, hash, err := WriteReaderAt(ctx, latencyWriter{bs, time.Millisecond},
+				bytes.NewReader(content), int64(len(content)), 64, fixity.DefaultMultihashName, concurrency)
+			if err != nil {
+				t.Fatalf("size %d concurrency %d: writereaderat: %v", contentSize, concurrency, err)
+			}
+			if len(refs) != len(expectRefs) || (len(refs) > 0 && !reflect.DeepEqual(refs, expectRefs)) {
+				t.Errorf("size %d concurrency %d: refs differ from sequential write",
+					contentSize, concurrency)
+			}
+			if size != expectSize {
+				t.Errorf("size %d concurrency %d: size want:%d, got:%d",
+					contentSize, concurrency, expectSize, size)
+			}
+			if hash != expectHash {
+				t.Errorf("size %d concurrency %d: hash want:%s, got:%s",
+					contentSize, concurrency, expectHash, hash)
+			}
+
+			var got []byte
+			for _, ref := range refs {
+				rc, err := bs.Read(ctx, ref)
+				if err != nil {
+					t.Fatalf("read chunk: %v", err)
+				}
+				b, _ := ioutil.ReadAll(rc)
+				rc.Close()
+				got = append(got, b...)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("size %d concurrency %d: chunk content differs", contentSize, concurrency)
+			}
+		}
+	}
+}
+
+func TestWriteReaderAtShortRead(t *testing.T) {
+	ctx := context.Background()
+	content := testContent(100)
+
+	// a size beyond the content must not write a zero filled tail.
+	_, _, _, err := WriteReaderAt(ctx, memory.New(), bytes.NewReader(content),
+		int64(len(content))+10, 64, fixity.DefaultMultihashName, 2)
+	if err == nil {
+		t.Fatalf("size beyond content want error")
+	}
+	if !strings.Contains(err.Error(), io.ErrUnexpectedEOF.Error()) {
+		t.Errorf("err want %v, got:%v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func benchmarkWriteChunks(b *testing.B, concurrency int) {
+	ctx := context.Background()
+	content := testContent(64 * 100)
+
+	for i := 0; i < b.N; i++ {
+		w := latencyWriter{BlobWriter: memory.New(), latency: time.Millisecond}
+		_, _, _, err := WriteChunksConcurrent(ctx, w,
+			&sliceChunker{b: content, size: 64}, fixity.DefaultMultihashName, concurrency)
+		if err != nil {
+			b.Fatalf("writechunksconcurrent: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteChunksSequential(b *testing.B)   { benchmarkWriteChunks(b, 1) }
+func BenchmarkWriteChunksConcurrent8(b *testing.B)  { benchmarkWriteChunks(b, 8) }
+func BenchmarkWriteChunksConcurrent32(b *testing.B) { benchmarkWriteChunks(b, 32) }
+
+func TestWriteData(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		Chunks int
+		Parts  int
+	}{
+		{Chunks: 0, Parts: 0},
+		{Chunks: 1, Parts: 0},
+		{Chunks: partSize - 1, Parts: 0},
+		{Chunks: partSize, Parts: 0},
+		{Chunks: partSize + 1, Parts: 1},
+		{Chunks: partSize * 2, Parts: 1},
+		{Chunks: partSize * 3, Parts: 2},
+		{Chunks: partSize*3 + 1, Parts: 3},
+	}
+
+	for _, tc := range testCases {
+		// extra capacity, confirming the caller's slice is not appended to.
+		chunkRefs := make([]fixity.Ref, tc.Chunks, tc.Chunks+10)
+		for i := range chunkRefs {
+			ref, err := fixity.Hash([]byte{byte(i), byte(i >> 8)})
+			if err != nil {
+				t.Fatalf("hash: %v", err)
+			}
+			chunkRefs[i] = ref
+		}
+		original := append([]fixity.Ref{}, chunkRefs...)
+
+		bs := memory.New()
+		refs, data, err := WriteData(ctx, bs, chunkRefs, int64(tc.Chunks), "checksum",
+			fixity.DefaultMultihashName)
+		if err != nil {
+			t.Fatalf("chunks:%d writedata: %v", tc.Chunks, err)
+		}
+
+		if want := tc.Chunks + tc.Parts + 1; len(refs) != want {
+			t.Errorf("chunks:%d refs want:%d, got:%d", tc.Chunks, want, len(refs))
+		}
+		if !reflect.DeepEqual(refs[:tc.Chunks], original) {
+			t.Errorf("chunks:%d refs do not begin with the chunk refs", tc.Chunks)
+		}
+		if extra := chunkRefs[:cap(chunkRefs)][tc.Chunks]; extra != "" {
+			t.Errorf("chunks:%d caller slice appended to", tc.Chunks)
+		}
+
+		var stored fixity.DataSchema
+		if err := readAndUnmarshal(ctx, bs, refs[len(refs)-1], &stored); err != nil {
+			t.Fatalf("chunks:%d read data: %v", tc.Chunks, err)
+		}
+		if !reflect.DeepEqual(stored.Parts, data.Parts) {
+			t.Errorf("chunks:%d returned data schema differs from stored", tc.Chunks)
+		}
+
+		// reassemble the linked parts, confirming the original order.
+		got := append([]fixity.Ref{}, stored.Parts...)
+		next, parts := stored.MoreParts, 0
+		for next != nil {
+			var part fixity.PartsSchema
+			if err := readAndUnmarshal(ctx, bs, *next, &part); err != nil {
+				t.Fatalf("chunks:%d read part: %v", tc.Chunks, err)
+			}
+			if n := len(part.Parts); n == 0 || n > partSize {
+				t.Errorf("chunks:%d part %d has %d refs", tc.Chunks, parts, n)
+			}
+			got = append(got, part.Parts...)
+			next = part.MoreParts
+			parts++
+		}
+
+		if parts != tc.Parts {
+			t.Errorf("chunks:%d parts want:%d, got:%d", tc.Chunks, tc.Parts, parts)
+		}
+		if len(got) != len(original) || (len(got) != 0 && !reflect.DeepEqual(got, original)) {
+			t.Errorf("chunks:%d reassembled refs do not match the original order", tc.Chunks)
+		}
+	}
+}
+
+func testChunkRefs(t testing.TB, n int) []fixity.Ref {
+	refs := make([]fixity.Ref, n)
+	for i := range refs {
+		ref, err := fixity.Hash([]byte{byte(i), byte(i >> 8)})
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		refs[i] = ref
+	}
+	return refs
+}
+
+func TestWriteDataConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	for _, chunks := range []int{0, 1, partSize, partSize + 1, partSize * 3, partSize*7 + 13} {
+		chunkRefs := testChunkRefs(t, chunks)
+
+		seqBs := memory.New()
+		wantRefs, wantData, err := WriteData(ctx, seqBs, chunkRefs, int64(chunks), "checksum",
+			fixity.DefaultMultihashName)
+		if err != nil {
+			t.Fatalf("chunks:%d writedata: %v", chunks, err)
+		}
+
+		bs := memory.New()
+		refs, data, err := WriteDataConcurrent(ctx, latencyWriter{bs, time.Millisecond},
+			chunkRefs, int64(chunks), "checksum", fixity.DefaultMultihashName, 4)
+		if err != nil {
+			t.Fatalf("chunks:%d writedataconcurrent: %v", chunks, err)
+		}
+
+		if !reflect.DeepEqual(refs, wantRefs) {
+			t.Errorf("chunks:%d refs differ from sequential", chunks)
+		}
+		if !reflect.DeepEqual(data, wantData) {
+			t.Errorf("chunks:%d data schema differs from sequential", chunks)
+		}
+
+		// every linked part must be stored, not only hashed.
+		for _, ref := range refs[chunks:] {
+			want, err := readAll(ctx, seqBs, ref)
+			if err != nil {
+				t.Fatalf("chunks:%d read sequential %s: %v", chunks, ref, err)
+			}
+			got, err := readAll(ctx, bs, ref)
+			if err != nil {
+				t.Fatalf("chunks:%d read %s: %v", chunks, ref, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("chunks:%d blob %s differs from sequential", chunks, ref)
+			}
+		}
+	}
+}
+
+func readAll(ctx context.Context, r fixity.BlobReader, ref fixity.Ref) ([]byte, error) {
+	rc, err := r.Read(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+func benchmarkWriteData(b *testing.B, concurrency int) {
+	ctx := context.Background()
+	chunkRefs := testChunkRefs(b, partSize*50)
+
+	for i := 0; i < b.N; i++ {
+		w := latencyWriter{BlobWriter: memory.New(), latency: time.Millisecond}
+		_, _, err := WriteDataConcurrent(ctx, w, chunkRefs, int64(len(chunkRefs)), "checksum",
+			fixity.DefaultMultihashName, concurrency)
+		if err != nil {
+			b.Fatalf("writedataconcurrent: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteDataSequential(b *testing.B)  { benchmarkWriteData(b, 1) }
+func BenchmarkWriteDataConcurrent8(b *testing.B) { benchmarkWriteData(b, 8) }
+
+func readAndUnmarshal(ctx context.Context, r fixity.BlobReader, ref fixity.Ref, v interface{}) error {
+	rc, err := r.Read(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func TestWriteValuesOrder(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		Name string
+		A, B fixity.Values
+	}{
+		{"list", fixity.Values{
+			"tag": value.List(value.String("a"), value.String("b")),
+		}, fixity.Values{
+			"tag": value.List(value.String("b"), value.String("a")),
+		}},
+		{"appended", fixity.Values{
+			"tag": value.String("a").Append(value.String("b")).Append(value.Int(1)),
+		}, fixity.Values{
+			"tag": value.Int(1).Append(value.String("b")).Append(value.String("a")),
+		}},
+		{"nested", fixity.Values{
+			"l": value.List(value.List(value.String("b"), value.String("a")), value.String("c")),
+		}, fixity.Values{
+			"l": value.List(value.String("c"), value.List(value.String("a"), value.String("b"))),
+		}},
+	}
+
+	for _, tc := range testCases {
+		aRef, err := WriteValues(ctx, memory.New(), tc.A)
+		if err != nil {
+			t.Fatalf("%s: writevalues: %v", tc.Name, err)
+		}
+		bRef, err := WriteValues(ctx, memory.New(), tc.B)
+		if err != nil {
+			t.Fatalf("%s: writevalues: %v", tc.Name, err)
+		}
+		if aRef != bRef {
+			t.Errorf("%s: list order changed ref: %s != %s", tc.Name, aRef, bRef)
+		}
+	}
+
+	// the caller's values are not sorted in place.
+	v := fixity.Values{"tag": value.List(value.String("b"), value.String("a"))}
+	if _, err := WriteValues(ctx, memory.New(), v); err != nil {
+		t.Fatalf("writevalues: %v", err)
+	}
+	if got := v["tag"].ListValue[0].StringValue; got != "b" {
+		t.Errorf("caller list want unmodified, got first:%s", got)
+	}
+}
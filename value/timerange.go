@@ -0,0 +1,96 @@
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is the absolute day format accepted by ParseTimeRange.
+const dateLayout = "2006-01-02"
+
+// ParseTimeRange parses a user supplied date expression into the
+// half open range [start, end) that the expression covers.
+//
+// Supported absolute forms:
+//
+//	2006-01-02             the whole day, in now's location.
+//	2006-01-02T15:04:05Z   the single second, RFC3339.
+//
+// Supported relative forms, resolved against now:
+//
+//	today                  the current day, until now.
+//	yesterday              the whole previous day.
+//	last-N-minutes         the N minutes leading up to now.
+//	last-N-hours           the N hours leading up to now.
+//	last-N-days            the N days leading up to now.
+//	last-N-weeks           the N weeks leading up to now.
+//
+// The singular unit forms, such as last-1-day, are also accepted.
+func ParseTimeRange(s string, now time.Time) (start, end time.Time, err error) {
+	switch s {
+	case "":
+		return time.Time{}, time.Time{}, fmt.Errorf("empty time expression")
+	case "today":
+		return startOfDay(now), now, nil
+	case "yesterday":
+		today := startOfDay(now)
+		return today.AddDate(0, 0, -1), today, nil
+	}
+
+	if strings.HasPrefix(s, "last-") {
+		d, err := parseLast(strings.TrimPrefix(s, "last-"))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%s: %v", s, err)
+		}
+		return now.Add(-d), now, nil
+	}
+
+	if t, err := time.ParseInLocation(dateLayout, s, now.Location()); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, t.Add(time.Second), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("unsupported time expression: %q", s)
+}
+
+// parseLast parses the N-unit portion of a last-N-unit expression.
+func parseLast(s string) (time.Duration, error) {
+	split := strings.SplitN(s, "-", 2)
+	if len(split) != 2 {
+		return 0, fmt.Errorf("expected N-unit")
+	}
+
+	n, err := strconv.Atoi(split[0])
+	if err != nil {
+		return 0, fmt.Errorf("atoi: %v", err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("N must be positive, got %d", n)
+	}
+
+	var unit time.Duration
+	switch split[1] {
+	case "minute", "minutes":
+		unit = time.Minute
+	case "hour", "hours":
+		unit = time.Hour
+	case "day", "days":
+		unit = 24 * time.Hour
+	case "week", "weeks":
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("unsupported unit: %q", split[1])
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,79 @@
+package value
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2023, 3, 15, 13, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Expr        string
+		ExpectStart time.Time
+		ExpectEnd   time.Time
+		ExpectErr   bool
+	}{
+		{
+			Expr:        "2023-01-01",
+			ExpectStart: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			ExpectEnd:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Expr:        "2023-01-01T10:00:00Z",
+			ExpectStart: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+			ExpectEnd:   time.Date(2023, 1, 1, 10, 0, 1, 0, time.UTC),
+		},
+		{
+			Expr:        "today",
+			ExpectStart: time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC),
+			ExpectEnd:   now,
+		},
+		{
+			Expr:        "yesterday",
+			ExpectStart: time.Date(2023, 3, 14, 0, 0, 0, 0, time.UTC),
+			ExpectEnd:   time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Expr:        "last-7-days",
+			ExpectStart: time.Date(2023, 3, 8, 13, 30, 0, 0, time.UTC),
+			ExpectEnd:   now,
+		},
+		{
+			Expr:        "last-1-hour",
+			ExpectStart: time.Date(2023, 3, 15, 12, 30, 0, 0, time.UTC),
+			ExpectEnd:   now,
+		},
+		{
+			Expr:      "last-0-days",
+			ExpectErr: true,
+		},
+		{
+			Expr:      "last-7-fortnights",
+			ExpectErr: true,
+		},
+		{
+			Expr:      "foo",
+			ExpectErr: true,
+		},
+	}
+	for _, testCase := range testCases {
+		start, end, err := ParseTimeRange(testCase.Expr, now)
+		if testCase.ExpectErr {
+			if err == nil {
+				t.Errorf("%s want error, got nil", testCase.Expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s unexpected error: %v", testCase.Expr, err)
+			continue
+		}
+		if !start.Equal(testCase.ExpectStart) {
+			t.Errorf("%s start want:%s, got:%s", testCase.Expr, testCase.ExpectStart, start)
+		}
+		if !end.Equal(testCase.ExpectEnd) {
+			t.Errorf("%s end want:%s, got:%s", testCase.Expr, testCase.ExpectEnd, end)
+		}
+	}
+}
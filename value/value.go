@@ -3,14 +3,17 @@ package value
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 )
 
 //go:generate stringer -type=Type -output=value_string.go
 
 type Value struct {
-	Type        Type   `json:"type"`
-	IntValue    int    `json:"intValue,omitempty"`
-	StringValue string `json:"stringValue,omitempty"`
+	Type        Type    `json:"type"`
+	IntValue    int     `json:"intValue,omitempty"`
+	StringValue string  `json:"stringValue,omitempty"`
+	ListValue   []Value `json:"listValue,omitempty"`
 }
 
 type Type int
@@ -18,6 +21,8 @@ type Type int
 const (
 	TypeInt    Type = 1
 	TypeString Type = 2
+	TypeTime   Type = 3
+	TypeList   Type = 4
 )
 
 func Int(v int) Value {
@@ -34,6 +39,59 @@ func String(v string) Value {
 	}
 }
 
+// Time returns a TypeTime Value.
+//
+// The time is stored in StringValue as RFC3339, so that adding time
+// values does not change the serialized form of existing values.
+func Time(t time.Time) Value {
+	return Value{
+		Type:        TypeTime,
+		StringValue: t.Format(time.RFC3339Nano),
+	}
+}
+
+// TimeValue returns the parsed time of a TypeTime Value.
+func (v Value) TimeValue() (time.Time, error) {
+	if v.Type != TypeTime {
+		return time.Time{}, fmt.Errorf("not a time value: %s", v.Type)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v.StringValue)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse: %v", err)
+	}
+
+	return t, nil
+}
+
+// List returns a TypeList Value of the given values.
+//
+// Lists allow a single field to hold multiple values, such as
+// multiple tags. Indexes match a list field if any of its values
+// match. Lists are unordered, and are stored sorted.
+func List(vs ...Value) Value {
+	return Value{
+		Type:      TypeList,
+		ListValue: vs,
+	}
+}
+
+// Append adds v to the existing value, converting the existing value
+// to a list if needed.
+//
+// Intended for building multi-value fields from repeated user input,
+// such as tag=a and tag=b.
+func (existing Value) Append(v Value) Value {
+	if existing.Type == TypeList {
+		// copied, so appending never writes to the backing array of the
+		// existing list, which may be shared with another value.
+		l := make([]Value, len(existing.ListValue), len(existing.ListValue)+1)
+		copy(l, existing.ListValue)
+		return List(append(l, v)...)
+	}
+	return List(existing, v)
+}
+
 // Value returns an untyped value of whatever value field is defined
 // by Value.Type.
 //
@@ -45,6 +103,18 @@ func (v Value) UntypedValue() (interface{}, error) {
 		return v.IntValue, nil
 	case TypeString:
 		return v.StringValue, nil
+	case TypeTime:
+		return v.TimeValue()
+	case TypeList:
+		l := make([]interface{}, len(v.ListValue))
+		for i, lv := range v.ListValue {
+			uv, err := lv.UntypedValue()
+			if err != nil {
+				return nil, fmt.Errorf("list index %d: %v", i, err)
+			}
+			l[i] = uv
+		}
+		return l, nil
 	default:
 		return nil, fmt.Errorf("unexpected value type: %s", v.Type)
 	}
@@ -55,8 +125,18 @@ func (v Value) ToString() (string, error) {
 	switch v.Type {
 	case TypeInt:
 		return strconv.Itoa(v.IntValue), nil
-	case TypeString:
+	case TypeString, TypeTime:
 		return v.StringValue, nil
+	case TypeList:
+		strs := make([]string, len(v.ListValue))
+		for i, lv := range v.ListValue {
+			s, err := lv.ToString()
+			if err != nil {
+				return "", fmt.Errorf("list index %d: %v", i, err)
+			}
+			strs[i] = s
+		}
+		return strings.Join(strs, ", "), nil
 	default:
 		return "", fmt.Errorf("unexpected value type: %s", v.Type)
 	}
@@ -68,6 +148,10 @@ func (v Value) String() string {
 		return fmt.Sprintf("IntValue(%d)", v.IntValue)
 	case TypeString:
 		return fmt.Sprintf("StringValue(%s)", v.StringValue)
+	case TypeTime:
+		return fmt.Sprintf("TimeValue(%s)", v.StringValue)
+	case TypeList:
+		return fmt.Sprintf("ListValue(%v)", v.ListValue)
 	default:
 		return "UnknownValue"
 	}
@@ -4,9 +4,9 @@ package value
 
 import "fmt"
 
-const _Type_name = "TypeIntTypeString"
+const _Type_name = "TypeIntTypeStringTypeTimeTypeList"
 
-var _Type_index = [...]uint8{0, 7, 17}
+var _Type_index = [...]uint8{0, 7, 17, 25, 33}
 
 func (i Type) String() string {
 	i -= 1
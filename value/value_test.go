@@ -0,0 +1,39 @@
+package value
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	v := String("a").Append(String("b")).Append(String("c"))
+
+	expect := List(String("a"), String("b"), String("c"))
+	if !reflect.DeepEqual(v, expect) {
+		t.Errorf("want:%s, got:%s", expect, v)
+	}
+
+	uv, err := v.UntypedValue()
+	if err != nil {
+		t.Fatalf("untypedvalue: %v", err)
+	}
+	expectUntyped := []interface{}{"a", "b", "c"}
+	if !reflect.DeepEqual(uv, expectUntyped) {
+		t.Errorf("untyped want:%v, got:%v", expectUntyped, uv)
+	}
+}
+
+func TestAppendDoesNotAlias(t *testing.T) {
+	base := List(String("a"), String("b"))
+	base.ListValue = append(make([]Value, 0, 10), base.ListValue...)
+
+	b := base.Append(String("c"))
+	c := base.Append(String("d"))
+
+	if want := List(String("a"), String("b"), String("c")); !reflect.DeepEqual(b, want) {
+		t.Errorf("want:%s, got:%s", want, b)
+	}
+	if want := List(String("a"), String("b"), String("d")); !reflect.DeepEqual(c, want) {
+		t.Errorf("want:%s, got:%s", want, c)
+	}
+}